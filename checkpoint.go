@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// checkpointFileExtension は -checkpoint-every で書き出し済みの位置を記録するファイルの拡張子です。
+const checkpointFileExtension = ".checkpoint"
+
+// Checkpoint は書き終えた最後の出力ファイルと、そこまでに読んだ入力の行番号です。
+// -checkpoint-every では出力を N 行ごとのファイルに分け、各ファイルを一時ファイルから
+// リネームして完成させた後に記録するので、記録した行までの出力は完全にそろっています。
+// -resume はその次の行から、次の番号のファイルに書き始めます。
+type Checkpoint struct {
+	Row   int `json:"row"`
+	Part  int `json:"part"`
+	Every int `json:"every"`
+}
+
+func checkpointFileName(tableName string) string {
+	return tableName + checkpointFileExtension
+}
+
+func readCheckpoint(tableName string) (Checkpoint, error) {
+	var checkpoint Checkpoint
+	data, err := os.ReadFile(checkpointFileName(tableName))
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoint, fmt.Errorf("no checkpoint %s to resume from", checkpointFileName(tableName))
+	}
+	if err != nil {
+		return checkpoint, fmt.Errorf("failed to read checkpoint: %s", err)
+	}
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return checkpoint, fmt.Errorf("invalid checkpoint %s: %s", checkpointFileName(tableName), err)
+	}
+	return checkpoint, nil
+}
+
+// writeCheckpoint は途中で止まっても壊れた記録が残らないよう、一時ファイルに書いてからリネームします。
+func writeCheckpoint(tableName string, checkpoint Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	fileName := checkpointFileName(tableName)
+	if err := os.WriteFile(fileName+temporaryFileSuffix, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %s", err)
+	}
+	if err := os.Rename(fileName+temporaryFileSuffix, fileName); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %s", err)
+	}
+	return nil
+}
+
+// removeCheckpoint は最後まで変換できた後に記録を消します。
+func removeCheckpoint(tableName string) error {
+	if err := os.Remove(checkpointFileName(tableName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint: %s", err)
+	}
+	return nil
+}
+
+func validateCheckpointOptions(options Options) error {
+	if options.CheckpointEvery < 0 {
+		return fmt.Errorf("invalid -checkpoint-every %d: must not be negative", options.CheckpointEvery)
+	}
+	if options.Resume && options.CheckpointEvery == 0 {
+		return fmt.Errorf("-resume requires -checkpoint-every")
+	}
+	if options.CheckpointEvery == 0 {
+		return nil
+	}
+	if options.SplitRows > 0 || options.ChunkByKeyRange > 0 || options.LoadData || options.Prepared || options.DryRunSQL {
+		return fmt.Errorf("-checkpoint-every cannot be used with -split-rows, -chunk-by-key-range, -load-data, -prepared or -dry-run-sql")
+	}
+	// 既出のキーは記録しないので、再開すると前回の出力と重複した行を落とせない
+	if options.Resume && options.DedupeKey != "" {
+		return fmt.Errorf("-resume cannot be used with -dedupe-key")
+	}
+	if options.Resume && options.InputDir != "" {
+		return fmt.Errorf("-resume cannot be used with -input-dir; resume each table separately")
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const checkpointSchema = "id,int,id,INT\nname,nvarchar,name,VARCHAR(10)\n"
+
+// checkpointInput は id が 1 から rows までの入力です。broken の行はフィールドが多すぎて変換を止めます。
+func checkpointInput(rows, broken int) string {
+	var input strings.Builder
+	input.WriteString("id,name\n")
+	for i := 1; i <= rows; i++ {
+		if i == broken {
+			input.WriteString(strings.Repeat("x,", 20) + "x\n")
+			continue
+		}
+		input.WriteString(strconv.Itoa(i) + ",n" + strconv.Itoa(i) + "\n")
+	}
+	return input.String()
+}
+
+func TestCheckpointResume(t *testing.T) {
+	files := map[string]string{"input.csv": checkpointInput(7, 6), "schema.csv": checkpointSchema}
+	if err := runConvert(t, files, "-checkpoint-every", "2", "t", "input.csv", "schema.csv"); err == nil {
+		t.Fatal("the broken row did not stop the conversion")
+	}
+	// 書き終えたファイルは残り、書きかけの3つ目は一時ファイルのまま完成させない
+	checkpoint, err := readCheckpoint("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Checkpoint{Row: 4, Part: 2, Every: 2}); checkpoint != want {
+		t.Errorf("checkpoint %+v, want %+v", checkpoint, want)
+	}
+	if _, err := os.Stat("t_003.SQL"); err == nil {
+		t.Error("t_003.SQL was completed after the failure")
+	}
+	if _, err := os.Stat("t_003.SQL" + temporaryFileSuffix); err != nil {
+		t.Errorf("the partial file was not kept: %s", err)
+	}
+
+	// 入力を直して再開すると、記録した行の次から次の番号のファイルに書く
+	if err := os.WriteFile("input.csv", []byte(checkpointInput(7, 0)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseArgs([]string{"convert", "-checkpoint-every", "2", "-resume", "t", "input.csv", "schema.csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ConvertFile(*parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"t_001.SQL", []string{"('1', 'n1')", "('2', 'n2')"}},
+		{"t_002.SQL", []string{"('3', 'n3')", "('4', 'n4')"}},
+		{"t_003.SQL", []string{"('5', 'n5')", "('6', 'n6')"}},
+		{"t_004.SQL", []string{"('7', 'n7')"}},
+	}
+	var all strings.Builder
+	for _, tt := range tests {
+		output := readOutput(t, tt.name)
+		all.WriteString(output)
+		for _, want := range tt.want {
+			if !strings.Contains(output, want) {
+				t.Errorf("%s: missing %s in\n%s", tt.name, want, output)
+			}
+		}
+	}
+	for i := 1; i <= 7; i++ {
+		if n := strings.Count(all.String(), "('"+strconv.Itoa(i)+"', "); n != 1 {
+			t.Errorf("row %d written %d times", i, n)
+		}
+	}
+	if _, err := os.Stat(checkpointFileName("t")); err == nil {
+		t.Error("the checkpoint was not removed after finishing")
+	}
+}
+
+func TestResumeFromCheckpointFile(t *testing.T) {
+	tests := []struct {
+		checkpoint string
+		flags      []string
+		wantErr    string
+	}{
+		{"", nil, "no checkpoint"},
+		{"not json", nil, "invalid checkpoint"},
+		{`{"row":2,"part":1,"every":3}`, nil, "-checkpoint-every 3"},
+	}
+	for _, tt := range tests {
+		files := map[string]string{"input.csv": checkpointInput(3, 0), "schema.csv": checkpointSchema}
+		if tt.checkpoint != "" {
+			files["t.checkpoint"] = tt.checkpoint
+		}
+		err := runConvert(t, files, "-checkpoint-every", "2", "-resume", "t", "input.csv", "schema.csv")
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("checkpoint %q: error %v, want %q", tt.checkpoint, err, tt.wantErr)
+		}
+	}
+
+	files := map[string]string{
+		"input.csv":    checkpointInput(3, 0),
+		"schema.csv":   checkpointSchema,
+		"t.checkpoint": `{"row":2,"part":1,"every":2}`,
+	}
+	if err := runConvert(t, files, "-checkpoint-every", "2", "-resume", "t", "input.csv", "schema.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if output := readOutput(t, "t_002.SQL"); !strings.Contains(output, "('3', 'n3')") || strings.Contains(output, "('2', 'n2')") {
+		t.Errorf("t_002.SQL:\n%s", output)
+	}
+	if _, err := os.Stat("t_001.SQL"); err == nil {
+		t.Error("resume rewrote t_001.SQL")
+	}
+}
+
+func TestCheckpointCleared(t *testing.T) {
+	// 再開しない実行では、前回の記録を消してから書き始める
+	files := map[string]string{
+		"input.csv":    checkpointInput(3, 0),
+		"schema.csv":   checkpointSchema,
+		"t.checkpoint": `{"row":2,"part":1,"every":2}`,
+	}
+	if err := runConvert(t, files, "-checkpoint-every", "2", "t", "input.csv", "schema.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(checkpointFileName("t")); err == nil {
+		t.Error("the stale checkpoint was kept")
+	}
+	if output := readOutput(t, "t_001.SQL"); !strings.Contains(output, "('1', 'n1')") {
+		t.Errorf("t_001.SQL:\n%s", output)
+	}
+}
+
+func TestCheckpointOptionsInvalid(t *testing.T) {
+	tests := [][]string{
+		{"-checkpoint-every", "-1"},
+		{"-resume"},
+		{"-checkpoint-every", "2", "-split-rows", "2"},
+		{"-checkpoint-every", "2", "-load-data"},
+		{"-checkpoint-every", "2", "-prepared"},
+		{"-checkpoint-every", "2", "-resume", "-dedupe-key", "id"},
+	}
+	for _, flags := range tests {
+		args := append(append([]string{"convert"}, flags...), "t", "input.csv", "schema.csv")
+		if _, err := ParseArgs(args); err == nil {
+			t.Errorf("ParseArgs accepted %q", flags)
+		}
+	}
+}
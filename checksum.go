@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+const (
+	ChecksumSHA1   = "sha1"
+	ChecksumMD5    = "md5"
+	ChecksumSHA256 = "sha256"
+)
+
+func newChecksumHash(algorithm string) (hash.Hash, bool) {
+	switch algorithm {
+	case ChecksumSHA1:
+		return sha1.New(), true
+	case ChecksumMD5:
+		return md5.New(), true
+	case ChecksumSHA256:
+		return sha256.New(), true
+	}
+	return nil, false
+}
+
+func validateChecksumOptions(options Options) error {
+	if _, ok := newChecksumHash(options.ChecksumAlgorithm); !ok {
+		return fmt.Errorf("invalid -checksum-algorithm %q: must be %s, %s or %s", options.ChecksumAlgorithm, ChecksumSHA1, ChecksumMD5, ChecksumSHA256)
+	}
+	return nil
+}
+
+// checksumColumn は -checksum-column で末尾に追加するカラムのスキーマを返します。
+// 値は16進数の文字列なので、転送先の型はハッシュの長さの CHAR にします。
+func checksumColumn(schema []Schema, options Options) (Schema, error) {
+	for _, column := range schema {
+		if strings.EqualFold(column.ColumnTo, options.ChecksumColumn) {
+			return Schema{}, fmt.Errorf("-checksum-column %q is already in the schema", options.ChecksumColumn)
+		}
+	}
+	h, _ := newChecksumHash(options.ChecksumAlgorithm)
+	return Schema{
+		DataTypeFrom: "varchar",
+		ColumnTo:     options.ChecksumColumn,
+		DataTypeTo:   fmt.Sprintf("CHAR(%d)", hex.EncodedLen(h.Size())),
+		Checksum:     options.ChecksumAlgorithm,
+	}, nil
+}
+
+// rowChecksum は変換前の元の値のハッシュを返します。値の区切りには入力に現れない
+// 単位区切り文字(0x1F)を使うので、("ab", "c") と ("a", "bc") は別のハッシュになります。
+func rowChecksum(algorithm string, values []string) string {
+	h, _ := newChecksumHash(algorithm)
+	h.Write([]byte(strings.Join(values, "\x1f")))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRowChecksum(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		values    []string
+		want      string
+	}{
+		{ChecksumSHA1, []string{"1", "apple"}, "20d511bb9b730f8cae1d4a7da2407e724c929cad"},
+		{ChecksumMD5, []string{"1", "apple"}, "2aacfa49a318a45a14ce54b336246a8e"},
+		{ChecksumSHA256, []string{"1", "apple"}, "7dac983e084e3f2a8ac5f779d628f56f085801a59ac9fb0577c10909a7f4d51e"},
+		// 区切りがあるので、値の境目が違えば別のハッシュになる
+		{ChecksumSHA1, []string{"ab", "c"}, "12dab451a9e2657f5f27a3be2ae14bb286b9309d"},
+		{ChecksumSHA1, []string{"a", "bc"}, "0e8056118ac28e13cd79e93fa9486ad6a1d7355e"},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 2; i++ { // 毎回同じ値になること
+			if got := rowChecksum(tt.algorithm, tt.values); got != tt.want {
+				t.Errorf("rowChecksum(%s, %q) = %s, want %s", tt.algorithm, tt.values, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestChecksumColumn(t *testing.T) {
+	files := map[string]string{
+		"input.csv":  "id,name\n1,apple\n2,O'Brien\n",
+		"schema.csv": "id,int,id,INT\nname,nvarchar,name,VARCHAR(20),trim\n",
+	}
+	if err := runConvert(t, files, "-checksum-column", "row_hash", "t", "input.csv", "schema.csv"); err != nil {
+		t.Fatal(err)
+	}
+	// ハッシュは変換前の元の値から求める
+	want := "INSERT INTO `t` (`id`, `name`, `row_hash`)\nVALUES\n" +
+		"('1', 'apple', '20d511bb9b730f8cae1d4a7da2407e724c929cad'),\n" +
+		"('2', 'O\\'Brien', '23d912d4b3673b2e43a22a858e0cf186abffdf2a');\n"
+	if got := readOutput(t, "t.SQL"); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestChecksumColumnErrors(t *testing.T) {
+	options := testOptions(t, "-checksum-column", "ID")
+	if _, err := checksumColumn(testSchema(t, "id,int,id,INT\n", options), options); err == nil || !strings.Contains(err.Error(), "already in the schema") {
+		t.Errorf("error %v, want the column to be rejected as a duplicate", err)
+	}
+	if _, err := ParseArgs([]string{"convert", "-checksum-column", "h", "-checksum-algorithm", "crc32", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted -checksum-algorithm crc32")
+	}
+}
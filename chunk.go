@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// keyRangeChunker は -chunk-by-key-range で、-chunk-key の整数値が 1〜1000、1001〜2000 のような
+// 範囲をまたいだところで出力ファイルを切り替えます。入力はキーの昇順に並んでいる前提で、
+// 前の範囲に戻るキーはエラーにします。行のない範囲のファイルは作りません。
+type keyRangeChunker struct {
+	index   int
+	column  string
+	size    int64
+	current int64
+	started bool
+}
+
+func newKeyRangeChunker(schema []Schema, options Options) (*keyRangeChunker, error) {
+	if options.ChunkByKeyRange == 0 {
+		return nil, nil
+	}
+	for i, column := range schema {
+		if column.ColumnTo == options.ChunkKey {
+			return &keyRangeChunker{index: i, column: column.ColumnTo, size: int64(options.ChunkByKeyRange)}, nil
+		}
+	}
+	return nil, fmt.Errorf("-chunk-key column %q is not in the schema", options.ChunkKey)
+}
+
+// next は行のキーが今の範囲を超えて、新しいファイルを始めるべきなら true を返します。
+func (c *keyRangeChunker) next(rowNumber int, values []Value) (bool, error) {
+	key, err := strconv.ParseInt(strings.TrimSpace(values[c.index].Text), 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("row %d: -chunk-key value %q is not an integer", rowNumber, values[c.index].Text)
+	}
+
+	// 範囲は 1 始まりで、0 以下のキーも同じ幅で区切る
+	chunk := (key - 1) / c.size
+	if key < 1 && (key-1)%c.size != 0 {
+		chunk--
+	}
+
+	switch {
+	case !c.started:
+		c.started, c.current = true, chunk
+		return false, nil
+	case chunk < c.current:
+		return false, fmt.Errorf("row %d: -chunk-key value %d comes after a larger key; -chunk-by-key-range needs input sorted by %s", rowNumber, key, c.column)
+	case chunk > c.current:
+		c.current = chunk
+		return true, nil
+	}
+	return false, nil
+}
+
+func validateChunkOptions(options Options) error {
+	if options.ChunkByKeyRange < 0 {
+		return fmt.Errorf("invalid -chunk-by-key-range %d: must not be negative", options.ChunkByKeyRange)
+	}
+	if (options.ChunkByKeyRange > 0) != (options.ChunkKey != "") {
+		return fmt.Errorf("-chunk-by-key-range and -chunk-key must be used together")
+	}
+	if options.ChunkByKeyRange > 0 && (options.SplitRows > 0 || options.LoadData || options.Prepared) {
+		return fmt.Errorf("-chunk-by-key-range cannot be used with -split-rows, -load-data or -prepared")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestKeyRangeChunker(t *testing.T) {
+	tests := []struct {
+		keys []string
+		want []bool // 各キーで新しいファイルを始めるかどうか
+	}{
+		{[]string{"1", "1000", "1001", "2000", "2001"}, []bool{false, false, true, false, true}},
+		{[]string{"5", "3500"}, []bool{false, true}}, // 行のない範囲は飛ばす
+		{[]string{"-1000", "-999", "0", "1"}, []bool{false, true, false, true}},
+		{[]string{" 7 ", "8"}, []bool{false, false}},
+	}
+	for _, tt := range tests {
+		chunker := &keyRangeChunker{column: "id", size: 1000}
+		for i, key := range tt.keys {
+			got, err := chunker.next(i+1, []Value{{Text: key}})
+			if err != nil {
+				t.Errorf("%q: key %q: %s", tt.keys, key, err)
+				break
+			}
+			if got != tt.want[i] {
+				t.Errorf("%q: key %q starts a new file: %v, want %v", tt.keys, key, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestKeyRangeChunkerErrors(t *testing.T) {
+	chunker := &keyRangeChunker{column: "id", size: 10}
+	if _, err := chunker.next(1, []Value{{Text: "25"}}); err != nil {
+		t.Fatal(err)
+	}
+	// 同じ範囲の中で戻るのはかまわない
+	if _, err := chunker.next(2, []Value{{Text: "21"}}); err != nil {
+		t.Error(err)
+	}
+	if _, err := chunker.next(3, []Value{{Text: "20"}}); err == nil || !strings.Contains(err.Error(), "needs input sorted by id") {
+		t.Errorf("error %v, want an unsorted key", err)
+	}
+	if _, err := chunker.next(4, []Value{{Text: "x"}}); err == nil || !strings.Contains(err.Error(), "is not an integer") {
+		t.Errorf("error %v, want a non-integer key", err)
+	}
+}
+
+func TestChunkByKeyRangeFiles(t *testing.T) {
+	files := map[string]string{
+		"input.csv":  "id\n1\n2\n3\n11\n31\n",
+		"schema.csv": "id,int,id,INT\n",
+	}
+	if err := runConvert(t, files, "-chunk-by-key-range", "10", "-chunk-key", "id", "t", "input.csv", "schema.csv"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"t_001.SQL": "('1'),\n('2'),\n('3');",
+		"t_002.SQL": "('11');",
+		"t_003.SQL": "('31');",
+	}
+	for name, tuples := range want {
+		if sql := readOutput(t, name); !strings.Contains(sql, "VALUES\n"+tuples) {
+			t.Errorf("%s:\n%s\nwant tuples\n%s", name, sql, tuples)
+		}
+	}
+	if _, err := os.Stat("t_004.SQL"); !os.IsNotExist(err) {
+		t.Errorf("t_004.SQL was written: %v", err)
+	}
+}
+
+func TestChunkOptionsInvalid(t *testing.T) {
+	for _, flags := range [][]string{
+		{"-chunk-by-key-range", "10"},
+		{"-chunk-key", "id"},
+		{"-chunk-by-key-range", "-1", "-chunk-key", "id"},
+		{"-chunk-by-key-range", "10", "-chunk-key", "id", "-split-rows", "5"},
+	} {
+		if _, err := ParseArgs(append(append([]string{"convert"}, flags...), "t", "input.csv", "schema.csv")); err == nil {
+			t.Errorf("ParseArgs accepted %q", flags)
+		}
+	}
+}
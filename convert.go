@@ -0,0 +1,357 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// errNoConversion は convertData に該当する変換がなく、値をそのまま通したことを表します。
+var errNoConversion = errors.New("no conversion for type mapping")
+
+// -max-row-length 省略時は、ヘッダーのカラム数のこの倍数を超える行で中断します
+const defaultRowLengthFactor = 4
+
+// rowConverter はスキーマに従って入力の1行を Value の並びに変換します。
+// SQL の組み立てとは独立しているので GenerateSQL と ConvertRows の両方で使います。
+type rowConverter struct {
+	schema         []Schema
+	destTypes      []DataType
+	headerIndexMap map[string]int
+	options        Options
+	report         *Report
+	dedupe         *deduper
+}
+
+func newRowConverter(schema []Schema, headerIndexMap map[string]int, options Options, report *Report) (*rowConverter, error) {
+	destTypes := make([]DataType, len(schema))
+	for i, column := range schema {
+		destTypes[i] = ParseDataType(column.DataTypeTo)
+	}
+
+	dedupe, err := newDeduper(schema, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rowConverter{
+		schema:         schema,
+		destTypes:      destTypes,
+		headerIndexMap: headerIndexMap,
+		options:        options,
+		report:         report,
+		dedupe:         dedupe,
+	}, nil
+}
+
+// errRejectedRow は rowReader.next が読めなかった行を飛ばしたことを表します。
+var errRejectedRow = errors.New("rejected row")
+
+// rowReader は入力から1行ずつ読み、変換の前に行全体に対する確認をします。
+type rowReader struct {
+	inputReader *csv.Reader
+	options     Options
+	maxFields   int
+	rowNumber   int
+	rejected    int
+}
+
+func (c *rowConverter) newRowReader(inputReader *csv.Reader) *rowReader {
+	maxFields := c.options.MaxRowLength
+	if maxFields == 0 {
+		maxFields = defaultRowLengthFactor * len(c.headerIndexMap)
+	}
+	return &rowReader{inputReader: inputReader, options: c.options, maxFields: maxFields}
+}
+
+// next は次の行を読みます。読めなかった行は -write-rejected のファイルに書いて errRejectedRow を返し、
+// 入力の終わりでは io.EOF を返します。それ以外のエラーでは変換を中断します。
+// -resume では、チェックポイントまでの行を変換せずに読み飛ばします。
+func (r *rowReader) next() (int, []string, error) {
+	for {
+		rowNumber, row, err := r.read()
+		if err == nil && rowNumber <= r.options.resume.Row {
+			continue
+		}
+		return rowNumber, row, err
+	}
+}
+
+func (r *rowReader) read() (int, []string, error) {
+	row, err := r.inputReader.Read()
+	if err == io.EOF {
+		return 0, nil, io.EOF
+	}
+	i := r.rowNumber
+	r.rowNumber++
+	if r.options.Rejected != nil {
+		r.options.Rejected.next(r.inputReader.InputOffset())
+	}
+	if r.options.TrimTrailingDelimiter && row != nil {
+		if row, err = trimTrailingDelimiter(i+1, row); err != nil {
+			return 0, nil, err
+		}
+	}
+	// 区切り文字がクォートされていない壊れたファイルは、以降の行も正しく読めないので中断する
+	if r.maxFields > 0 && len(row) > r.maxFields {
+		return 0, nil, fmt.Errorf("row %d has %d fields, more than the limit of %d; the input may contain unquoted delimiters", i+1, len(row), r.maxFields)
+	}
+	if err != nil {
+		fmt.Printf("failed to read row %d: %s\n", i, err)
+		if r.options.Rejected != nil {
+			if err := r.options.Rejected.reject(r.rejected); err != nil {
+				return 0, nil, err
+			}
+		}
+		r.rejected++
+		return i + 1, nil, errRejectedRow
+	}
+	return i + 1, row, nil
+}
+
+// each は入力を1行ずつ変換し、-dedupe-key で重複した行を除いて fn に渡します。
+// rowNumber はヘッダーを除いたデータ行の番号(1始まり)です。
+// -parallel-within-file 指定時は変換を並行して行いますが、fn には入力の順に渡します。
+func (c *rowConverter) each(inputReader *csv.Reader, fn func(rowNumber int, values []Value) error) error {
+	if c.options.ParallelWithinFile > 1 {
+		return c.eachParallel(inputReader, fn)
+	}
+
+	reader := c.newRowReader(inputReader)
+	for {
+		rowNumber, row, err := reader.next()
+		switch {
+		case err == io.EOF:
+			return nil
+		case errors.Is(err, errRejectedRow):
+			c.report.RejectedRows++
+			continue
+		case err != nil:
+			return err
+		}
+
+		values, err := c.convert(rowNumber, row, c.report)
+		if err != nil {
+			return err
+		}
+		if err := c.emit(rowNumber, values, fn); err != nil {
+			return err
+		}
+	}
+}
+
+// emit は重複した行を除いて fn に渡します。カラムごとの値の数は、重複を除いた後の行で数えます。
+func (c *rowConverter) emit(rowNumber int, values []Value, fn func(rowNumber int, values []Value) error) error {
+	if c.dedupe != nil && c.dedupe.duplicate(values) {
+		c.report.DuplicateRows++
+		return nil
+	}
+	c.report.addColumnValues(c.schema, values)
+	return fn(rowNumber, values)
+}
+
+// convert は1行を変換します。警告などは report に記録するので、並行して呼ぶ場合は行ごとに別の Report を渡します。
+func (c *rowConverter) convert(rowNumber int, row []string, report *Report) ([]Value, error) {
+	values := make([]Value, 0, len(c.schema))
+	for j, column := range c.schema {
+		var value string
+		if column.Checksum != "" {
+			value = c.checksum(column.Checksum, rowNumber, row)
+		} else {
+			value = sourceValue(column, rowNumber, row, c.headerIndexMap)
+			// -replace-global は値全体が一致する場合だけ、スキーマの変換より前に置き換える
+			if replacement, ok := c.options.globalReplacements[value]; ok && column.Expression == nil {
+				if replacement.isNull {
+					values = append(values, nullValue)
+					continue
+				}
+				value = replacement.value
+			}
+			// 閉じていないクォートなどで1つのフィールドが巨大になった場合に備える
+			if c.options.MaxValueBytes > 0 && len(value) > c.options.MaxValueBytes {
+				message := fmt.Sprintf("value is %d bytes, larger than -max-value-bytes %d", len(value), c.options.MaxValueBytes)
+				if c.options.MaxValueAction == MaxValueError {
+					return nil, fmt.Errorf("row %d, column %s: %s; the input may contain an unterminated quote", rowNumber, column.ColumnTo, message)
+				}
+				report.Warn(rowNumber, column.ColumnTo, "%s; truncated", message)
+				value = truncateBytes(value, c.options.MaxValueBytes)
+			}
+			before := value
+			value = applyTransforms(value, column.Transforms)
+			if c.options.TransformAudit > 0 && len(column.Transforms) > 0 {
+				report.addTransformSample(TransformSample{Row: rowNumber, Column: column.ColumnTo, Before: before, After: value}, c.options.TransformAudit)
+			}
+		}
+		if c.options.FlattenWhitespace && c.destTypes[j].IsText() {
+			value = flattenWhitespace(value)
+		}
+		if value == "" && column.HasTransform("default") {
+			values = append(values, defaultValue)
+			continue
+		}
+		if value == "" && c.options.nullEmptyFor[c.destTypes[j].Name] {
+			values = append(values, nullValue)
+			continue
+		}
+
+		if len(c.options.DateFormats) > 0 && value != "" && isTextSourceType(column.DataTypeFrom) && isDateDestType(c.destTypes[j]) {
+			date, layout, err := parseMixedDate(value, c.destTypes[j], c.options)
+			if err != nil {
+				report.Warn(rowNumber, column.ColumnTo, "%s", err)
+			} else {
+				report.addDateFormat(column.ColumnTo, layout)
+			}
+			values = append(values, c.overrideQuoting(Value{Text: date}))
+			continue
+		}
+
+		convertedValue, err := convertData(value, column.DataTypeFrom, c.destTypes[j], c.options)
+		switch {
+		case errors.Is(err, errNoConversion):
+			report.addUnconverted(column.DataTypeFrom, c.destTypes[j].Name)
+		case err != nil:
+			report.Warn(rowNumber, column.ColumnTo, "%s", err)
+		}
+		if length, ok := c.destTypes[j].CharLength(); ok {
+			if n := utf8.RuneCountInString(convertedValue.Text); n > length {
+				report.Warn(rowNumber, column.ColumnTo, "value is %d characters long and will be truncated or rejected by %s", n, column.DataTypeTo)
+			}
+		}
+		if c.options.FourByteCheck != FourByteCheckOff && convertedValue.Kind == StringValue {
+			if r, ok := findFourByteRune(convertedValue.Text); ok {
+				message := fmt.Sprintf("value contains the 4-byte character %U, which needs a utf8mb4 column", r)
+				if c.options.FourByteCheck == FourByteCheckError {
+					return nil, fmt.Errorf("row %d, column %s: %s", rowNumber, column.ColumnTo, message)
+				}
+				report.Warn(rowNumber, column.ColumnTo, "%s", message)
+			}
+		}
+
+		if column.Expression.IsRowNumber() {
+			convertedValue.Kind = NumberValue
+		}
+
+		values = append(values, c.overrideQuoting(convertedValue))
+	}
+	return values, nil
+}
+
+// checksum はチェックサム以外のカラムの、変換前の元の値のハッシュを返します。
+func (c *rowConverter) checksum(algorithm string, rowNumber int, row []string) string {
+	values := make([]string, 0, len(c.schema))
+	for _, column := range c.schema {
+		if column.Checksum == "" {
+			values = append(values, sourceValue(column, rowNumber, row, c.headerIndexMap))
+		}
+	}
+	return rowChecksum(algorithm, values)
+}
+
+// overrideQuoting は -quote-all/-quote-none 指定時に、型に応じたクォートの有無を上書きします。
+// DEFAULT などのキーワードは対象外です。
+func (c *rowConverter) overrideQuoting(value Value) Value {
+	switch {
+	case c.options.QuoteAll && value.Kind == NumberValue:
+		value.Kind = StringValue
+	case c.options.QuoteNone && value.Kind == StringValue:
+		value.Kind = NumberValue
+	}
+	return value
+}
+
+// ConvertRows は SQL 文を組み立てずに、変換・エスケープ済みの値を行ごとに返します。
+// 各値は GenerateSQL が VALUES に書き出すものと同じ表記(文字列はクォート付き、数値はそのまま)です。
+func ConvertRows(schema []Schema, headerIndexMap map[string]int, inputReader *csv.Reader, options Options) ([]string, [][]string, *Report, error) {
+	report := &Report{}
+	converter, err := newRowConverter(schema, headerIndexMap, options, report)
+	if err != nil {
+		return nil, nil, report, err
+	}
+
+	columns := make([]string, 0, len(schema))
+	for _, column := range schema {
+		columns = append(columns, column.ColumnTo)
+	}
+
+	var rows [][]string
+	err = converter.each(inputReader, func(rowNumber int, values []Value) error {
+		literals := make([]string, 0, len(values))
+		for _, value := range values {
+			literals = append(literals, value.SQL(options.noBackslashEscapes))
+		}
+		rows = append(rows, literals)
+		report.Rows++
+		return nil
+	})
+	if err != nil {
+		return nil, nil, report, err
+	}
+
+	return columns, rows, report, nil
+}
+
+func sourceValue(column Schema, rowNumber int, row []string, headerIndexMap map[string]int) string {
+	if column.Expression != nil {
+		return column.Expression.Evaluate(rowNumber, row, headerIndexMap)
+	}
+	headerIndex := headerIndexMap[column.ColumnFrom]
+	return row[headerIndex]
+}
+
+func convertData(value, srcType string, destType DataType, options Options) (Value, error) {
+	if destType.Name == "YEAR" && isYearSourceType(srcType) {
+		return convertYear(value, options) // 日付や年の文字列から年だけを取り出す
+	}
+
+	srcName := sourceTypeName(srcType)
+	switch srcName {
+	case "int":
+		switch destType.Name {
+		case "VARCHAR":
+			return Value{Text: value}, nil // 文字列として扱う
+		case "ENUM":
+			return convertBoolToEnum(value, destType) // 0/1 を ENUM のメンバーに対応させる
+		}
+		if _, ok := integerBits(destType.Name); ok {
+			return convertInteger(value, destType, options) // MySQLの整数型として範囲を確認する
+		}
+	case "bit", "tinyint", "smallint", "bigint":
+		if destType.Name == "ENUM" {
+			return convertBoolToEnum(value, destType)
+		}
+		if _, ok := integerBits(destType.Name); ok {
+			return convertInteger(value, destType, options)
+		}
+	case "nvarchar", "varchar":
+		if destType.Name == "SET" {
+			return convertSet(value, destType) // カンマ区切りの各要素を SET のメンバーと照合する
+		}
+		return Value{Text: value}, nil // 基本的にそのまま文字列として扱う
+	case "date":
+		if options.DateOutputFormat != "" {
+			return convertDate(value, options) // -date-output-format の書式に直す
+		}
+	case "datetime", "datetime2", "smalldatetime":
+		if _, ok := integerBits(destType.Name); ok && options.DatetimeEpoch != "" {
+			return convertDatetimeToEpoch(value, destType, options) // 整数のカラムには Unix 時間として入れる
+		}
+		return convertDatetime(value, destType, options) // MySQLのDATETIMEに対応。タイムゾーンと小数秒は指定時のみ変換する
+	case "uniqueidentifier":
+		switch destType.Name {
+		case "BINARY", "VARBINARY":
+			return convertUUIDToBinary(value, options) // 16バイトのバイナリとして扱う
+		}
+	case "decimal", "numeric", "money", "smallmoney", "float", "real":
+		switch destType.Name {
+		case "DECIMAL", "NUMERIC", "DEC", "FIXED":
+			return convertDecimal(value, destType) // 指数表記も通常の小数表記に展開する
+		}
+		// 2^63 以上の ID は SQL Server の bigint に入らないので decimal(20,0) で持たれていることが多い
+		if _, ok := integerBits(destType.Name); ok && (srcName == "decimal" || srcName == "numeric") {
+			return convertInteger(value, destType, options)
+		}
+	}
+	return Value{Text: value}, errNoConversion
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOverLengthWarning(t *testing.T) {
+	schema := "code,nvarchar,code,CHAR(3)\nname,nvarchar,name,VARCHAR(5)\nnote,nvarchar,note,TEXT\n"
+	tests := []struct {
+		row  string
+		want []Warning
+	}{
+		{"abc,abcde," + strings.Repeat("x", 100), nil},
+		{"abcd,abcde,x", []Warning{{Row: 1, Column: "code", Message: "value is 4 characters long and will be truncated or rejected by CHAR(3)"}}},
+		{"abc,abcdef,x", []Warning{{Row: 1, Column: "name", Message: "value is 6 characters long and will be truncated or rejected by VARCHAR(5)"}}},
+		{"日本語,あいうえお,x", nil}, // バイト数ではなく文字数で数える
+	}
+	for _, tt := range tests {
+		_, report, err := generate(t, schema, "code,name,note\n"+tt.row+"\n", testOptions(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Warnings) != len(tt.want) {
+			t.Errorf("%s: warnings = %v, want %v", tt.row, report.Warnings, tt.want)
+			continue
+		}
+		for i := range tt.want {
+			if report.Warnings[i] != tt.want[i] {
+				t.Errorf("%s: warning = %v, want %v", tt.row, report.Warnings[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestConvertRows(t *testing.T) {
+	options := testOptions(t)
+	schema := testSchema(t, `id,int,id,BIGINT UNSIGNED
+name,nvarchar,name,VARCHAR(20)
+price,decimal,price,"DECIMAL(10,2)"
+created,datetime,created,DATETIME
+status,nvarchar,status,VARCHAR(10),default
+`, options)
+	reader := csv.NewReader(strings.NewReader("id,name,price,created,status\n7,It's,1.5E+1,2023-01-02 03:04:05,\n"))
+	headers, err := ParseHeaders(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns, rows, report, err := ConvertRows(schema, MapHeadersToSchema(headers, schema), reader, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"id", "name", "price", "created", "status"}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("columns = %q, want %q", columns, want)
+	}
+	want := [][]string{{"'7'", `'It\'s'`, "15.00", "'2023-01-02 03:04:05'", "DEFAULT"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+	if report.Rows != 1 {
+		t.Errorf("report.Rows = %d, want 1", report.Rows)
+	}
+}
+
+func TestDefaultTransform(t *testing.T) {
+	schema := "id,int,id,INT\nstatus,nvarchar,status,VARCHAR(10),default\nnote,nvarchar,note,VARCHAR(10)\n"
+	sql, _, err := generate(t, schema, "id,status,note\n1,,\n2,active,x\n", testOptions(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"('1', DEFAULT, '')", "('2', 'active', 'x')"} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("output does not contain %s:\n%s", want, sql)
+		}
+	}
+	if strings.Contains(sql, "'DEFAULT'") {
+		t.Errorf("DEFAULT is quoted:\n%s", sql)
+	}
+}
+
+func TestQuoteOverrides(t *testing.T) {
+	schema := `id,int,id,INT
+name,nvarchar,name,VARCHAR(20)
+price,decimal,price,"DECIMAL(10,2)"
+status,nvarchar,status,VARCHAR(10),default
+`
+	input := "id,name,price,status\n1,abc,2.5,\n"
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{nil, "('1', 'abc', 2.50, DEFAULT)"},
+		{[]string{"-quote-all"}, "('1', 'abc', '2.50', DEFAULT)"},
+		{[]string{"-quote-none"}, "(1, abc, 2.50, DEFAULT)"},
+	}
+	for _, tt := range tests {
+		sql, _, err := generate(t, schema, input, testOptions(t, tt.flags...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(sql, "VALUES\n"+tt.want+";") {
+			t.Errorf("%q: output\n%s\nwant %s", tt.flags, sql, tt.want)
+		}
+	}
+}
+
+func TestQuoteOverridesExclusive(t *testing.T) {
+	if _, err := ParseArgs([]string{"convert", "-quote-all", "-quote-none", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted -quote-all with -quote-none")
+	}
+}
+
+func TestMaxRowLength(t *testing.T) {
+	schema := "a,int,a,INT\nb,int,b,INT\nc,int,c,INT\n"
+	wide := strings.TrimSuffix(strings.Repeat("1,", 5000), ",")
+	tests := []struct {
+		name     string
+		flags    []string
+		row      string
+		wantErr  string
+		rejected int
+	}{
+		{"pathologically wide row", nil, wide, "row 2 has 5000 fields, more than the limit of 12", 0},
+		{"within the default limit", nil, "1,2,3,4", "", 1}, // 列数の違う行は読めない行として飛ばす
+		{"custom limit", []string{"-max-row-length", "3"}, "1,2,3,4", "row 2 has 4 fields, more than the limit of 3", 0},
+		{"at the custom limit", []string{"-max-row-length", "3"}, "1,2,3", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, report, err := generate(t, schema, "a,b,c\n1,2,3\n"+tt.row+"\n", testOptions(t, tt.flags...))
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.RejectedRows != tt.rejected {
+				t.Errorf("RejectedRows = %d, want %d", report.RejectedRows, tt.rejected)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DataType はスキーマに書かれた MySQL の型定義を分解したものです。
+//
+//	VARCHAR(255)        -> Name: VARCHAR, Args: [255]
+//	DECIMAL(18,2)       -> Name: DECIMAL, Args: [18 2]
+//	TINYINT(3) UNSIGNED -> Name: TINYINT, Args: [3], Unsigned: true
+type DataType struct {
+	Name     string
+	Args     []string
+	Unsigned bool
+}
+
+func ParseDataType(definition string) DataType {
+	definition = strings.TrimSpace(definition)
+
+	var dataType DataType
+	rest := definition
+	if open := strings.IndexByte(definition, '('); open >= 0 {
+		if close := strings.LastIndexByte(definition, ')'); close > open {
+			dataType.Name = strings.ToUpper(strings.TrimSpace(definition[:open]))
+			dataType.Args = splitTypeArgs(definition[open+1 : close])
+			rest = definition[close+1:]
+		}
+	}
+
+	if dataType.Name == "" {
+		fields := strings.Fields(definition)
+		if len(fields) > 0 {
+			dataType.Name = strings.ToUpper(fields[0])
+			rest = strings.Join(fields[1:], " ")
+		}
+	}
+
+	for _, modifier := range strings.Fields(strings.ToUpper(rest)) {
+		if modifier == "UNSIGNED" {
+			dataType.Unsigned = true
+		}
+	}
+
+	return dataType
+}
+
+// sourceTypeName はスキーマの DataTypeFrom の型名を小文字で返します。
+// decimal(18,2) や nvarchar(50) のように精度や長さを書いても、変換は型名で選びます。
+func sourceTypeName(definition string) string {
+	return strings.ToLower(ParseDataType(definition).Name)
+}
+
+// splitTypeArgs は括弧内の引数をカンマで分割します。ENUM('a,b') のような
+// クォート内のカンマでは分割しません。
+func splitTypeArgs(args string) []string {
+	var result []string
+	var arg strings.Builder
+	inQuote := false
+	for i := 0; i < len(args); i++ {
+		c := args[i]
+		switch {
+		case c == '\'':
+			inQuote = !inQuote
+			arg.WriteByte(c)
+		case c == ',' && !inQuote:
+			result = append(result, strings.TrimSpace(arg.String()))
+			arg.Reset()
+		default:
+			arg.WriteByte(c)
+		}
+	}
+	if s := strings.TrimSpace(arg.String()); s != "" || len(result) > 0 {
+		result = append(result, s)
+	}
+	return result
+}
+
+// CharLength は CHAR/VARCHAR 系の型で宣言された文字数を返します。
+func (t DataType) CharLength() (int, bool) {
+	switch t.Name {
+	case "CHAR", "VARCHAR", "NCHAR", "NVARCHAR":
+	default:
+		return 0, false
+	}
+	if len(t.Args) != 1 {
+		return 0, false
+	}
+	length, err := strconv.Atoi(t.Args[0])
+	if err != nil {
+		return 0, false
+	}
+	return length, true
+}
+
+// DecimalPrecision は DECIMAL(p,s) の精度とスケールを返します。
+// 省略時は MySQL の既定値 DECIMAL(10,0) と同じ扱いです。
+func (t DataType) DecimalPrecision() (precision, scale int) {
+	precision, scale = 10, 0
+	if len(t.Args) > 0 {
+		if p, err := strconv.Atoi(t.Args[0]); err == nil {
+			precision = p
+		}
+	}
+	if len(t.Args) > 1 {
+		if s, err := strconv.Atoi(t.Args[1]); err == nil {
+			scale = s
+		}
+	}
+	return precision, scale
+}
+
+// Members は ENUM('a','b') / SET('a','b') のメンバーをクォートを外して返します。
+func (t DataType) Members() []string {
+	members := make([]string, 0, len(t.Args))
+	for _, arg := range t.Args {
+		if len(arg) >= 2 && arg[0] == '\'' && arg[len(arg)-1] == '\'' {
+			arg = arg[1 : len(arg)-1]
+		}
+		arg = strings.ReplaceAll(arg, "''", "'")
+		arg = strings.ReplaceAll(arg, "\\'", "'")
+		members = append(members, arg)
+	}
+	return members
+}
+
+// IsText は文字列を格納する CHAR/VARCHAR/TEXT 系の型かどうかを返します。
+func (t DataType) IsText() bool {
+	switch t.Name {
+	case "CHAR", "VARCHAR", "NCHAR", "NVARCHAR", "TINYTEXT", "TEXT", "MEDIUMTEXT", "LONGTEXT":
+		return true
+	}
+	return false
+}
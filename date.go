@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// isTextSourceType は SQL Server の文字列型かどうかを返します。
+func isTextSourceType(srcType string) bool {
+	switch sourceTypeName(srcType) {
+	case "char", "varchar", "nchar", "nvarchar", "text", "ntext":
+		return true
+	}
+	return false
+}
+
+// isDateDestType は -date-format で日付として読み直す MySQL の型かどうかを返します。
+func isDateDestType(destType DataType) bool {
+	switch destType.Name {
+	case "DATE", "DATETIME", "TIMESTAMP":
+		return true
+	}
+	return false
+}
+
+// parseMixedDate は -date-format の書式(Go の time レイアウト)を指定順に試し、
+// 最初に読めた書式で value を MySQL の日付表記(-date-output-format の指定があればその書式)に直します。
+// 使った書式も返します。
+func parseMixedDate(value string, destType DataType, options Options) (string, string, error) {
+	trimmed := strings.TrimSpace(value)
+	for _, layout := range options.DateFormats {
+		t, err := time.Parse(layout, trimmed)
+		if err != nil {
+			continue
+		}
+		if options.DateOutputFormat != "" {
+			return t.Format(options.DateOutputFormat), layout, nil
+		}
+		if destType.Name == "DATE" {
+			return t.Format("2006-01-02"), layout, nil
+		}
+		return t.Format(datetimeOutputLayout), layout, nil
+	}
+	return value, "", fmt.Errorf("%q matches none of the -date-format layouts", value)
+}
+
+// convertDate は date の値を -date-output-format の書式に直します。
+// 日付だけの値なので、-source-tz/-target-tz での時差の調整はしません。
+func convertDate(value string, options Options) (Value, error) {
+	if value == "" {
+		return Value{Text: value}, nil
+	}
+	t, err := time.Parse("2006-01-02", strings.TrimSpace(value))
+	if err != nil {
+		return Value{Text: value}, fmt.Errorf("%q is not a date; left unchanged", value)
+	}
+	return Value{Text: t.Format(options.DateOutputFormat)}, nil
+}
+
+// validateDateOutputFormat は -date-output-format が日時の要素を含み、書き出した値を同じ書式で
+// 読み戻せることを確かめます。2006 を 2016 のように書き間違えると、すべての値が同じ文字列になるためです。
+func validateDateOutputFormat(layout string) error {
+	if layout == "" {
+		return nil
+	}
+	sample := time.Date(2017, time.November, 28, 19, 38, 42, 123456789, time.UTC)
+	formatted := sample.Format(layout)
+	if formatted == layout {
+		return fmt.Errorf("invalid -date-output-format %q: contains no elements of the reference time 2006-01-02 15:04:05", layout)
+	}
+	if _, err := time.Parse(layout, formatted); err != nil {
+		return fmt.Errorf("invalid -date-output-format %q: %s", layout, err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMixedDate(t *testing.T) {
+	options := testOptions(t, "-date-format", "2006-01-02", "-date-format", "01/02/2006", "-date-format", "2006年1月2日")
+	tests := []struct {
+		value    string
+		destType string
+		want     string
+		layout   string
+		wantErr  bool
+	}{
+		{"2023-04-05", "DATE", "2023-04-05", "2006-01-02", false},
+		{"04/05/2023", "DATE", "2023-04-05", "01/02/2006", false},
+		{" 2023年4月5日 ", "DATE", "2023-04-05", "2006年1月2日", false},
+		{"04/05/2023", "DATETIME", "2023-04-05 00:00:00", "01/02/2006", false},
+		{"05.04.2023", "DATE", "05.04.2023", "", true},
+		{"13/01/2023", "DATE", "13/01/2023", "", true},
+	}
+	for _, tt := range tests {
+		got, layout, err := parseMixedDate(tt.value, ParseDataType(tt.destType), options)
+		if got != tt.want || layout != tt.layout || (err != nil) != tt.wantErr {
+			t.Errorf("parseMixedDate(%q, %s) = %q, %q, %v; want %q, %q", tt.value, tt.destType, got, layout, err, tt.want, tt.layout)
+		}
+	}
+}
+
+func TestMixedDateColumn(t *testing.T) {
+	const schema = "born,nvarchar,born,DATE\nname,nvarchar,name,VARCHAR(10)\n"
+	const input = "born,name\n2023-04-05,a\n04/06/2023,b\n04/07/2023,c\nunknown,d\n,e\n"
+	sql, report, err := generate(t, schema, input, testOptions(t, "-date-format", "2006-01-02", "-date-format", "01/02/2006"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"('2023-04-05', 'a')", "('2023-04-06', 'b')", "('2023-04-07', 'c')", "('unknown', 'd')", "('', 'e')"} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("missing %s in\n%s", want, sql)
+		}
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0].Row != 4 {
+		t.Errorf("warnings %v, want one for row 4", report.Warnings)
+	}
+	if report.DateFormats[DateFormatUsage{Column: "born", Layout: "01/02/2006"}] != 2 || report.DateFormats[DateFormatUsage{Column: "born", Layout: "2006-01-02"}] != 1 {
+		t.Errorf("date formats %v", report.DateFormats)
+	}
+}
+
+func TestDateOutputFormat(t *testing.T) {
+	const schema = "born,date,born,VARCHAR(20)\n" +
+		"created,datetime,created,VARCHAR(30)\n" +
+		"updated,smalldatetime,updated,VARCHAR(30)\n" +
+		"logged,datetime2,logged,VARCHAR(30)\n"
+	const input = "born,created,updated,logged\n2023-04-05,2023-04-05 13:14:15.123,2023-04-05 13:14:00,2023-04-05 13:14:15.1234567\n"
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{nil, "('2023-04-05', '2023-04-05 13:14:15.123', '2023-04-05 13:14:00', '2023-04-05 13:14:15.1234567')"},
+		{[]string{"-date-output-format", "2006-01-02T15:04:05"}, "('2023-04-05T00:00:00', '2023-04-05T13:14:15', '2023-04-05T13:14:00', '2023-04-05T13:14:15')"},
+		{[]string{"-date-output-format", "02/01/2006"}, "('05/04/2023', '05/04/2023', '05/04/2023', '05/04/2023')"},
+		{[]string{"-date-output-format", "20060102150405.000"}, "('20230405000000.000', '20230405131415.123', '20230405131400.000', '20230405131415.123')"},
+		// タイムゾーンを変換した後で書式を適用する。date の値はずらさない
+		{[]string{"-date-output-format", "2006-01-02 15:04", "-source-tz", "Asia/Tokyo", "-target-tz", "UTC"}, "('2023-04-05 00:00', '2023-04-05 04:14', '2023-04-05 04:14', '2023-04-05 04:14')"},
+	}
+	for _, tt := range tests {
+		sql, _, err := generate(t, schema, input, testOptions(t, tt.flags...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(sql, tt.want) {
+			t.Errorf("%q: got\n%s\nwant %s", tt.flags, sql, tt.want)
+		}
+	}
+}
+
+func TestDateOutputFormatFromMixedDate(t *testing.T) {
+	options := testOptions(t, "-date-format", "01/02/2006", "-date-output-format", "2006.01.02")
+	got, _, err := parseMixedDate("04/05/2023", ParseDataType("DATE"), options)
+	if err != nil || got != "2023.04.05" {
+		t.Errorf("parseMixedDate = %q, %v; want %q", got, err, "2023.04.05")
+	}
+}
+
+func TestConvertDate(t *testing.T) {
+	options := testOptions(t, "-date-output-format", "Jan 2, 2006")
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"2023-04-05", "Apr 5, 2023", false},
+		{" 2023-12-31 ", "Dec 31, 2023", false},
+		{"", "", false},
+		{"2023/04/05", "2023/04/05", true},
+		{"2023-02-30", "2023-02-30", true},
+	}
+	for _, tt := range tests {
+		got, err := convertDate(tt.value, options)
+		if got.Text != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("convertDate(%q) = %q, %v; want %q", tt.value, got.Text, err, tt.want)
+		}
+	}
+}
+
+func TestValidateDateOutputFormat(t *testing.T) {
+	tests := []struct {
+		layout  string
+		wantErr bool
+	}{
+		{"", false},
+		{"2006-01-02", false},
+		{"2006-01-02T15:04:05.000Z07:00", false},
+		{"02 Jan 06 15:04", false},
+		{"YYYY-MM-DD", true},       // 参照時刻の要素を含まない
+		{"2016/11/28 19:38", true}, // 書き出した値を読み戻せない
+		{"2006-01-02 15:04:05.0000000000", true},
+	}
+	for _, tt := range tests {
+		if err := validateDateOutputFormat(tt.layout); (err != nil) != tt.wantErr {
+			t.Errorf("validateDateOutputFormat(%q) = %v, wantErr %v", tt.layout, err, tt.wantErr)
+		}
+	}
+	if _, err := ParseArgs([]string{"convert", "-date-output-format", "YYYY-MM-DD", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted -date-output-format YYYY-MM-DD")
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// datetimeLayouts は SQL Server の datetime/datetime2/smalldatetime として受け付ける書式です。
+// 小数秒は桁数を問わず読み取ります。
+var datetimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+const datetimeOutputLayout = "2006-01-02 15:04:05.999999999"
+
+const (
+	DatetimeEpochSeconds      = "seconds"
+	DatetimeEpochMilliseconds = "milliseconds"
+)
+
+const (
+	FractionalSecondsRound    = "round"
+	FractionalSecondsTruncate = "truncate"
+)
+
+func validateFractionalSeconds(mode string) error {
+	switch mode {
+	case "", FractionalSecondsRound, FractionalSecondsTruncate:
+		return nil
+	}
+	return fmt.Errorf("invalid -fractional-seconds %q: must be %s or %s", mode, FractionalSecondsRound, FractionalSecondsTruncate)
+}
+
+func validateDatetimeEpoch(unit string) error {
+	switch unit {
+	case "", DatetimeEpochSeconds, DatetimeEpochMilliseconds:
+		return nil
+	}
+	return fmt.Errorf("invalid -datetime-epoch %q: must be %s or %s", unit, DatetimeEpochSeconds, DatetimeEpochMilliseconds)
+}
+
+func parseDatetime(value string) (time.Time, bool) {
+	for _, layout := range datetimeLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// fractionalScale は DATETIME(3) のような型の小数秒の桁数を返します。省略時は 0 です。
+func fractionalScale(destType DataType) int {
+	if len(destType.Args) == 0 {
+		return 0
+	}
+	scale, err := strconv.Atoi(strings.TrimSpace(destType.Args[0]))
+	if err != nil || scale < 0 {
+		return 0
+	}
+	if scale > 6 {
+		return 6
+	}
+	return scale
+}
+
+// hasFractionalSeconds は小数秒の桁数を持つ MySQL の型かどうかを返します。
+// VARCHAR(50) の 50 のような他の型の引数は桁数として読みません。
+func hasFractionalSeconds(destType DataType) bool {
+	switch destType.Name {
+	case "DATETIME", "TIMESTAMP", "TIME":
+		return true
+	}
+	return false
+}
+
+// convertDatetime は datetime/datetime2/smalldatetime の値を、-source-tz/-target-tz、-fractional-seconds と
+// -date-output-format に従って書き直します。いずれも指定がなければ値をそのまま返します。
+//
+// MySQL は DATETIME(n) の桁数を超える小数秒を丸めて保存するので、.9999995 は次の秒になることがあります。
+// -fractional-seconds truncate は切り捨てて、元の秒を保ちます。小数秒を持たない型には適用しません。
+func convertDatetime(value string, destType DataType, options Options) (Value, error) {
+	fractional := options.FractionalSeconds != "" && hasFractionalSeconds(destType)
+	if (options.sourceLocation == nil && !fractional && options.DateOutputFormat == "") || value == "" {
+		return Value{Text: value}, nil
+	}
+
+	t, ok := parseDatetime(value)
+	if !ok {
+		return Value{Text: value}, fmt.Errorf("%q is not a datetime; left unchanged", value)
+	}
+
+	var err error
+	if options.sourceLocation != nil {
+		t, err = shiftZone(t, options)
+	}
+
+	if !fractional {
+		if options.DateOutputFormat != "" {
+			return Value{Text: t.Format(options.DateOutputFormat)}, err
+		}
+		return Value{Text: t.Format(datetimeOutputLayout)}, err
+	}
+	scale := fractionalScale(destType)
+	unit := time.Second
+	for i := 0; i < scale; i++ {
+		unit /= 10
+	}
+	if options.FractionalSeconds == FractionalSecondsRound {
+		t = t.Round(unit)
+	} else {
+		t = t.Truncate(unit)
+	}
+	layout := "2006-01-02 15:04:05"
+	if scale > 0 {
+		layout += "." + strings.Repeat("0", scale)
+	}
+	if options.DateOutputFormat != "" {
+		layout = options.DateOutputFormat
+	}
+	return Value{Text: t.Format(layout)}, err
+}
+
+// convertDatetimeToEpoch は datetime/datetime2/smalldatetime の値を、整数のカラムに入れる Unix 時間(秒またはミリ秒)にします。
+// 値は -source-tz の時刻として読み、指定がなければ UTC とみなします。Unix 時間はタイムゾーンに
+// よらないので、-target-tz は結果に影響しません。ミリ秒未満は切り捨てます。
+func convertDatetimeToEpoch(value string, destType DataType, options Options) (Value, error) {
+	if value == "" {
+		return Value{Text: value}, nil
+	}
+	t, ok := parseDatetime(value)
+	if !ok {
+		return Value{Text: value}, fmt.Errorf("%q is not a datetime; left unchanged", value)
+	}
+
+	var err error
+	if options.sourceLocation != nil {
+		t, err = shiftZone(t, options)
+	}
+
+	epoch := t.Unix()
+	if options.DatetimeEpoch == DatetimeEpochMilliseconds {
+		epoch = t.UnixMilli()
+	}
+	text := strconv.FormatInt(epoch, 10)
+	if rangeErr := checkIntegerRange(text, destType); rangeErr != nil {
+		return Value{Text: value}, fmt.Errorf("%s as Unix time: %s", value, rangeErr)
+	}
+	return Value{Text: text, Kind: NumberValue}, err
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFractionalSeconds(t *testing.T) {
+	tests := []struct {
+		mode     string
+		destType string
+		value    string
+		want     string
+	}{
+		{FractionalSecondsRound, "DATETIME(6)", "2023-12-31 23:59:59.9999995", "2024-01-01 00:00:00.000000"},
+		{FractionalSecondsTruncate, "DATETIME(6)", "2023-12-31 23:59:59.9999995", "2023-12-31 23:59:59.999999"},
+		{FractionalSecondsRound, "DATETIME", "2023-12-31 23:59:59.5", "2024-01-01 00:00:00"},
+		{FractionalSecondsTruncate, "DATETIME", "2023-12-31 23:59:59.5", "2023-12-31 23:59:59"},
+		{FractionalSecondsRound, "DATETIME(3)", "2023-01-02T03:04:05.1235", "2023-01-02 03:04:05.124"},
+		{FractionalSecondsTruncate, "TIMESTAMP(3)", "2023-01-02 03:04:05.1239", "2023-01-02 03:04:05.123"},
+		{FractionalSecondsTruncate, "DATETIME(6)", "2023-01-02 03:04:05", "2023-01-02 03:04:05.000000"},
+		// 小数秒を持たない型の引数は桁数として読まず、値をそのまま通す
+		{FractionalSecondsTruncate, "VARCHAR(50)", "2023-12-31 23:59:59.9999995", "2023-12-31 23:59:59.9999995"},
+		{FractionalSecondsRound, "DATETIME(6)", "", ""},
+	}
+	for _, tt := range tests {
+		got, err := convertDatetime(tt.value, ParseDataType(tt.destType), testOptions(t, "-fractional-seconds", tt.mode))
+		if err != nil {
+			t.Errorf("%s %s %q: %s", tt.mode, tt.destType, tt.value, err)
+			continue
+		}
+		if got.Text != tt.want {
+			t.Errorf("%s %s %q = %q, want %q", tt.mode, tt.destType, tt.value, got.Text, tt.want)
+		}
+	}
+}
+
+func TestFractionalSecondsInvalid(t *testing.T) {
+	if _, err := convertDatetime("2023-13-01 00:00:00", ParseDataType("DATETIME(6)"), testOptions(t, "-fractional-seconds", "round")); err == nil {
+		t.Error("convertDatetime accepted month 13")
+	}
+	if _, err := ParseArgs([]string{"convert", "-fractional-seconds", "ceil", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted -fractional-seconds ceil")
+	}
+}
+
+func TestFractionalScale(t *testing.T) {
+	tests := map[string]int{"DATETIME": 0, "DATETIME(3)": 3, "DATETIME(6)": 6, "DATETIME(9)": 6, "TIME(x)": 0}
+	for destType, want := range tests {
+		if got := fractionalScale(ParseDataType(destType)); got != want {
+			t.Errorf("fractionalScale(%s) = %d, want %d", destType, got, want)
+		}
+	}
+}
+
+func TestDatetimeEpoch(t *testing.T) {
+	tests := []struct {
+		flags    []string
+		destType string
+		value    string
+		want     string
+		wantErr  bool
+	}{
+		{[]string{"-datetime-epoch", "seconds"}, "BIGINT", "1970-01-01 00:00:00", "0", false},
+		{[]string{"-datetime-epoch", "seconds"}, "BIGINT", "2023-01-02 03:04:05.999", "1672628645", false},
+		{[]string{"-datetime-epoch", "milliseconds"}, "BIGINT", "2023-01-02 03:04:05.9999", "1672628645999", false},
+		{[]string{"-datetime-epoch", "seconds"}, "INT", "1969-12-31 23:59:59", "-1", false},
+		// -source-tz の時刻として読み、-target-tz は影響しない
+		{[]string{"-datetime-epoch", "seconds", "-source-tz", "Asia/Tokyo", "-target-tz", "America/New_York"}, "BIGINT", "1970-01-01 09:00:00", "0", false},
+		{[]string{"-datetime-epoch", "seconds"}, "INT UNSIGNED", "2106-02-07 06:28:15", "4294967295", false},
+		{[]string{"-datetime-epoch", "seconds"}, "INT", "2038-01-19 03:14:08", "2038-01-19 03:14:08", true},
+		{[]string{"-datetime-epoch", "milliseconds"}, "INT", "2023-01-02 03:04:05", "2023-01-02 03:04:05", true},
+		{[]string{"-datetime-epoch", "seconds"}, "BIGINT", "yesterday", "yesterday", true},
+		{[]string{"-datetime-epoch", "seconds"}, "BIGINT", "", "", false},
+	}
+	for _, tt := range tests {
+		got, err := convertDatetimeToEpoch(tt.value, ParseDataType(tt.destType), testOptions(t, tt.flags...))
+		if got.Text != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%q %s %q = %q, %v; want %q", tt.flags, tt.destType, tt.value, got.Text, err, tt.want)
+		}
+	}
+}
+
+func TestDatetimeEpochColumn(t *testing.T) {
+	schema := "created,datetime2,created,BIGINT\nupdated,datetime,updated,DATETIME\n"
+	sql, _, err := generate(t, schema, "created,updated\n2023-01-02 03:04:05,2023-01-02 03:04:05\n", testOptions(t, "-datetime-epoch", "seconds"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 整数のカラムだけを Unix 時間にし、数値として出力する
+	if want := "(1672628645, '2023-01-02 03:04:05')"; !strings.Contains(sql, want) {
+		t.Errorf("got\n%s\nwant %s", sql, want)
+	}
+	if _, err := ParseArgs([]string{"convert", "-datetime-epoch", "minutes", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted -datetime-epoch minutes")
+	}
+}
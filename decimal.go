@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// convertDecimal は 1.23E+4 のような指数表記を含む数値を、DECIMAL(p,s) の
+// スケールに丸めた通常の小数表記に変換します。丸めは MySQL と同じく四捨五入です。
+func convertDecimal(value string, destType DataType) (Value, error) {
+	if value == "" {
+		return Value{Text: value}, nil
+	}
+
+	// big.Rat は 1/2 のような分数も読むが、SQL Server の decimal の出力に分数は現れないので壊れた値として扱う
+	if strings.Contains(value, "/") {
+		return Value{Text: value}, fmt.Errorf("%q is not a valid number", value)
+	}
+	number, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return Value{Text: value}, fmt.Errorf("%q is not a valid number", value)
+	}
+
+	precision, scale := destType.DecimalPrecision()
+	text := number.FloatString(scale)
+	if strings.Trim(text, "-0.") == "" {
+		text = strings.TrimPrefix(text, "-") // -0.00 のような表記にしない
+	}
+
+	integerPart, _, _ := strings.Cut(strings.TrimPrefix(text, "-"), ".")
+	integerDigits := len(integerPart)
+	if integerPart == "0" {
+		integerDigits = 0
+	}
+	if integerDigits > precision-scale {
+		return Value{Text: text, Kind: NumberValue}, fmt.Errorf("%s is out of range for DECIMAL(%d,%d)", text, precision, scale)
+	}
+
+	return Value{Text: text, Kind: NumberValue}, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertDecimal(t *testing.T) {
+	tests := []struct {
+		value    string
+		destType string
+		want     string
+		wantErr  bool
+	}{
+		{"1.23E+4", "DECIMAL(10,2)", "12300.00", false},
+		{"1.23e4", "DECIMAL(10,0)", "12300", false},
+		{"-4.5E-3", "DECIMAL(10,4)", "-0.0045", false},
+		{"1.2345E+1", "DECIMAL(10,2)", "12.35", false}, // 四捨五入
+		{"-1.2345E+1", "DECIMAL(10,2)", "-12.35", false},
+		{"1E-10", "DECIMAL(10,2)", "0.00", false},
+		{"-1E-10", "DECIMAL(10,2)", "0.00", false}, // -0.00 にしない
+		{"123.456", "DECIMAL", "123", false},
+		{"9.99E+2", "DECIMAL(3,0)", "999", false},
+		{"1E+3", "DECIMAL(3,0)", "1000", true},
+		{"1.5E", "DECIMAL(10,2)", "1.5E", true},
+		{"1/2", "DECIMAL(10,2)", "1/2", true}, // 分数は読まない
+		{" -3/4 ", "DECIMAL(10,2)", " -3/4 ", true},
+		{"", "DECIMAL(10,2)", "", false},
+	}
+	for _, tt := range tests {
+		got, err := convertDecimal(tt.value, ParseDataType(tt.destType))
+		if got.Text != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("convertDecimal(%q, %s) = %q, %v; want %q, error %v", tt.value, tt.destType, got.Text, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestExponentialDecimalOutput(t *testing.T) {
+	tests := []struct {
+		srcType string
+		value   string
+		want    string
+	}{
+		{"decimal", "1.23E+4", "(12300.00)"},
+		{"numeric(18,2)", "2.5E-1", "(0.25)"},
+		{"float", "1E+2", "(100.00)"},
+	}
+	for _, tt := range tests {
+		schema := "amount," + tt.srcType + `,amount,"DECIMAL(10,2)"` + "\n"
+		sql, _, err := generate(t, schema, "amount\n"+tt.value+"\n", testOptions(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "VALUES\n" + tt.want + ";\n"; !strings.HasSuffix(sql, want) {
+			t.Errorf("%s %s: output\n%s\nwant it to end with %q", tt.srcType, tt.value, sql, want)
+		}
+	}
+}
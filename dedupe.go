@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// deduper は -dedupe-key のカラムの値が既出の行を検出します。
+// 既出のキーは出力全体で保持するので、-split-rows でファイルが分かれても重複を落とせます。
+type deduper struct {
+	indexes []int
+	seen    map[string]struct{}
+	fold    func(string) string // -dedupe-ignore-case/-dedupe-ignore-accents でキーを正規化する。nil ならそのまま比べる
+}
+
+func newDeduper(schema []Schema, options Options) (*deduper, error) {
+	keyColumns := options.DedupeKey
+	if keyColumns == "" {
+		return nil, nil
+	}
+
+	d := &deduper{seen: make(map[string]struct{}), fold: dedupeFolder(options)}
+	for _, name := range strings.Split(keyColumns, ",") {
+		name = strings.TrimSpace(name)
+		index := -1
+		for i, column := range schema {
+			if column.ColumnTo == name {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("-dedupe-key column %q is not in the schema", name)
+		}
+		d.indexes = append(d.indexes, index)
+	}
+	return d, nil
+}
+
+// duplicate は行のキーが既出なら true を返し、初出なら記録します。
+func (d *deduper) duplicate(values []Value) bool {
+	parts := make([]string, 0, len(d.indexes))
+	for _, index := range d.indexes {
+		part := values[index].SQL(false)
+		if d.fold != nil {
+			part = d.fold(part)
+		}
+		parts = append(parts, part)
+	}
+	key := strings.Join(parts, "\x00")
+
+	if _, ok := d.seen[key]; ok {
+		return true
+	}
+	d.seen[key] = struct{}{}
+	return false
+}
+
+// dedupeFolder は SQL Server の _CI/_AI の照合順序にならい、大文字小文字やアクセントだけが違う
+// キーを同じとみなすための正規化を返します。emit は1つのゴルーチンから呼ぶので、状態を持つ
+// Caser や Transformer を使い回しても問題ありません。
+func dedupeFolder(options Options) func(string) string {
+	var folds []func(string) string
+	if options.DedupeIgnoreAccents {
+		// NFD で基底文字と結合文字に分け、結合文字 (é の ´ など) を取り除く
+		stripAccents := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+		folds = append(folds, func(value string) string {
+			result, _, err := transform.String(stripAccents, value)
+			if err != nil {
+				return value
+			}
+			return result
+		})
+	}
+	if options.DedupeIgnoreCase {
+		caser := cases.Fold()
+		folds = append(folds, caser.String)
+	}
+	if len(folds) == 0 {
+		return nil
+	}
+
+	return func(value string) string {
+		for _, fold := range folds {
+			value = fold(value)
+		}
+		return value
+	}
+}
+
+func validateDedupeOptions(options Options) error {
+	if options.DedupeKey == "" {
+		if options.DedupeIgnoreCase {
+			return fmt.Errorf("-dedupe-ignore-case requires -dedupe-key")
+		}
+		if options.DedupeIgnoreAccents {
+			return fmt.Errorf("-dedupe-ignore-accents requires -dedupe-key")
+		}
+	}
+	return nil
+}
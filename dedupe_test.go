@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDedupeAcrossSplitFiles(t *testing.T) {
+	files := map[string]string{
+		"input.csv":  "id,name\n1,a\n2,b\n3,c\n1,a again\n4,d\n2,b again\n",
+		"schema.csv": "id,int,id,INT\nname,nvarchar,name,VARCHAR(10)\n",
+	}
+	if err := runConvert(t, files, "-dedupe-key", "id", "-split-rows", "2", "t", "input.csv", "schema.csv"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"t_001.SQL": "('1', 'a'),\n('2', 'b');",
+		"t_002.SQL": "('3', 'c'),\n('4', 'd');",
+	}
+	for name, tuples := range want {
+		if sql := readOutput(t, name); !strings.Contains(sql, "VALUES\n"+tuples) {
+			t.Errorf("%s:\n%s\nwant tuples\n%s", name, sql, tuples)
+		}
+	}
+	if sql := readOutput(t, "t_002.SQL"); strings.Contains(sql, "again") {
+		t.Errorf("t_002.SQL keeps a duplicate of a key written to t_001.SQL:\n%s", sql)
+	}
+}
+
+func TestDedupeKeyColumns(t *testing.T) {
+	schema := "a,int,a,INT\nb,nvarchar,b,VARCHAR(10)\n"
+	input := "a,b\n1,x\n1,y\n1,x\n2,x\n"
+	tests := []struct {
+		key  string
+		rows int
+	}{
+		{"a", 2},
+		{"a,b", 3},
+		{"b", 2},
+	}
+	for _, tt := range tests {
+		_, report, err := generate(t, schema, input, testOptions(t, "-dedupe-key", tt.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Rows != tt.rows || report.DuplicateRows != 4-tt.rows {
+			t.Errorf("-dedupe-key %s: %d rows, %d duplicates; want %d rows", tt.key, report.Rows, report.DuplicateRows, tt.rows)
+		}
+	}
+}
+
+func TestDedupeFolder(t *testing.T) {
+	tests := []struct {
+		flags []string
+		a, b  string
+		same  bool
+	}{
+		{nil, "Café", "cafe", false},
+		{[]string{"-dedupe-ignore-case"}, "ABC", "abc", true},
+		{[]string{"-dedupe-ignore-case"}, "Straße", "STRASSE", true},
+		{[]string{"-dedupe-ignore-case"}, "Café", "cafe", false},
+		{[]string{"-dedupe-ignore-accents"}, "Café", "Cafe", true},
+		{[]string{"-dedupe-ignore-accents"}, "Café", "cafe", false},
+		// 合成済みの é と e + 結合文字を同じとみなす
+		{[]string{"-dedupe-ignore-accents"}, "Caf\u00e9", "Cafe\u0301", true},
+		{[]string{"-dedupe-ignore-case", "-dedupe-ignore-accents"}, "CAFÉ", "cafe", true},
+		{[]string{"-dedupe-ignore-case", "-dedupe-ignore-accents"}, "café", "cafes", false},
+	}
+	for _, tt := range tests {
+		fold := dedupeFolder(testOptions(t, append(tt.flags, "-dedupe-key", "name")...))
+		if fold == nil {
+			fold = func(value string) string { return value }
+		}
+		if same := fold(tt.a) == fold(tt.b); same != tt.same {
+			t.Errorf("%q: %q and %q are the same: %v, want %v", tt.flags, tt.a, tt.b, same, tt.same)
+		}
+	}
+}
+
+func TestDedupeIgnoreCaseAndAccents(t *testing.T) {
+	const schema = "name,nvarchar,name,VARCHAR(10)\n"
+	const input = "name\nCafé\ncafe\nCAFE\nCafe\u0301\n"
+	tests := []struct {
+		flags []string
+		rows  int
+	}{
+		{nil, 4},
+		{[]string{"-dedupe-ignore-case"}, 3},
+		{[]string{"-dedupe-ignore-accents"}, 3},
+		{[]string{"-dedupe-ignore-case", "-dedupe-ignore-accents"}, 1},
+	}
+	for _, tt := range tests {
+		sql, report, err := generate(t, schema, input, testOptions(t, append(tt.flags, "-dedupe-key", "name")...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Rows != tt.rows || report.DuplicateRows != 4-tt.rows {
+			t.Errorf("%q: %d rows, %d duplicates; want %d rows", tt.flags, report.Rows, report.DuplicateRows, tt.rows)
+		}
+		// 最初に現れた表記を残す
+		if !strings.Contains(sql, "('Café')") {
+			t.Errorf("%q: the first row is not kept:\n%s", tt.flags, sql)
+		}
+	}
+	for _, flag := range []string{"-dedupe-ignore-case", "-dedupe-ignore-accents"} {
+		if _, err := ParseArgs([]string{"convert", flag, "t", "input.csv", "schema.csv"}); err == nil {
+			t.Errorf("ParseArgs accepted %s without -dedupe-key", flag)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DiffSummary は -preview-diff で入力の各行を現在のテーブルと比べた結果です。
+type DiffSummary struct {
+	Inserts   int
+	Updates   int
+	Unchanged int
+}
+
+// currentRows は転送先テーブルの現在の行を、キーカラムの値で引けるようにしたものです。
+type currentRows struct {
+	keyIndexes []int       // schema 上のキーカラムの位置
+	columns    map[int]int // schema 上の位置 -> 現在の CSV のカラム位置
+	rows       map[string][]string
+}
+
+// readCurrentRows は転送先テーブルをヘッダー付きでダンプした CSV を読み込みます。
+// ヘッダーは転送先のカラム名(ColumnTo)で、スキーマにないカラムや足りないカラムは比較しません。
+// keyColumns を省略した場合はスキーマの先頭カラムをキーにします。
+func readCurrentRows(fileName string, schema []Schema, keyColumns string) (*currentRows, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open current rows file: %s", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read current rows file: %s", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("current rows file is empty")
+	}
+
+	current := &currentRows{columns: make(map[int]int), rows: make(map[string][]string)}
+	for i, header := range records[0] {
+		header = strings.TrimSpace(string(removeBOM([]byte(header))))
+		for j, column := range schema {
+			if strings.EqualFold(column.ColumnTo, header) {
+				current.columns[j] = i
+			}
+		}
+	}
+
+	if keyColumns == "" && len(schema) > 0 {
+		keyColumns = schema[0].ColumnTo
+	}
+	for _, name := range strings.Split(keyColumns, ",") {
+		name = strings.TrimSpace(name)
+		index := -1
+		for i, column := range schema {
+			if column.ColumnTo == name {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("-diff-key column %q is not in the schema", name)
+		}
+		if _, ok := current.columns[index]; !ok {
+			return nil, fmt.Errorf("-diff-key column %q is not in the current rows file", name)
+		}
+		current.keyIndexes = append(current.keyIndexes, index)
+	}
+
+	for i, record := range records[1:] {
+		if len(record) != len(records[0]) {
+			return nil, fmt.Errorf("current rows file line %d: expected %d fields, got %d", i+2, len(records[0]), len(record))
+		}
+		parts := make([]string, 0, len(current.keyIndexes))
+		for _, index := range current.keyIndexes {
+			parts = append(parts, record[current.columns[index]])
+		}
+		current.rows[strings.Join(parts, "\x00")] = record
+	}
+	return current, nil
+}
+
+// classify は変換後の行が新規・更新・変更なしのどれにあたるかを数えます。
+// 値は出力される表記(クォート前の文字列)で比べます。
+func (c *currentRows) classify(values []Value, summary *DiffSummary) {
+	parts := make([]string, 0, len(c.keyIndexes))
+	for _, index := range c.keyIndexes {
+		parts = append(parts, values[index].Text)
+	}
+	record, ok := c.rows[strings.Join(parts, "\x00")]
+	if !ok {
+		summary.Inserts++
+		return
+	}
+
+	for j, i := range c.columns {
+		if values[j].Text != record[i] {
+			summary.Updates++
+			return
+		}
+	}
+	summary.Unchanged++
+}
+
+// PreviewDiff は SQL を書き出さずに、入力の各行を現在のテーブルの行と比べて分類します。
+func PreviewDiff(schema []Schema, headerIndexMap map[string]int, inputReader *csv.Reader, options Options) (*DiffSummary, *Report, error) {
+	report := &Report{}
+	current, err := readCurrentRows(options.PreviewDiff, schema, options.DiffKey)
+	if err != nil {
+		return nil, report, err
+	}
+
+	converter, err := newRowConverter(schema, headerIndexMap, options, report)
+	if err != nil {
+		return nil, report, err
+	}
+
+	summary := &DiffSummary{}
+	err = converter.each(inputReader, func(rowNumber int, values []Value) error {
+		current.classify(values, summary)
+		report.Rows++
+		return nil
+	})
+	if err != nil {
+		return nil, report, err
+	}
+	return summary, report, nil
+}
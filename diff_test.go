@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// previewDiff は current を現在のテーブルのダンプとして PreviewDiff を実行します。
+func previewDiff(t *testing.T, schemaCSV, inputCSV, current string, flags ...string) (*DiffSummary, error) {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), "current.csv")
+	if err := os.WriteFile(fileName, []byte(current), 0644); err != nil {
+		t.Fatal(err)
+	}
+	options := testOptions(t, append([]string{"-preview-diff", fileName}, flags...)...)
+	schema := testSchema(t, schemaCSV, options)
+	reader := csv.NewReader(strings.NewReader(inputCSV))
+	headers, err := ParseHeaders(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, _, err := PreviewDiff(schema, MapHeadersToSchema(headers, schema), reader, options)
+	return summary, err
+}
+
+func TestPreviewDiff(t *testing.T) {
+	const schema = "UserID,int,id,INT\nUserName,nvarchar,name,VARCHAR(20)\nRegion,nvarchar,region,VARCHAR(10)\n"
+	const input = "UserID,UserName,Region\n1,alice,east\n2,bob,west\n3,carol,east\n4,dave,north\n"
+	tests := []struct {
+		name    string
+		current string
+		flags   []string
+		want    DiffSummary
+	}{
+		{
+			name:    "first column key",
+			current: "id,name,region\n1,alice,east\n2,bobby,west\n3,carol,east\n",
+			want:    DiffSummary{Inserts: 1, Updates: 1, Unchanged: 2},
+		},
+		{
+			// ダンプにないカラムは比べない
+			name:    "missing column",
+			current: "\ufeffID,Name\n1,alice\n2,bob\n",
+			want:    DiffSummary{Inserts: 2, Unchanged: 2},
+		},
+		{
+			name:    "composite key",
+			current: "id,name,region\n1,alice,west\n3,carol,east\n",
+			flags:   []string{"-diff-key", "id,region"},
+			want:    DiffSummary{Inserts: 3, Unchanged: 1},
+		},
+		{
+			name:    "empty table",
+			current: "id,name,region\n",
+			want:    DiffSummary{Inserts: 4},
+		},
+	}
+	for _, tt := range tests {
+		summary, err := previewDiff(t, schema, input, tt.current, tt.flags...)
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if *summary != tt.want {
+			t.Errorf("%s: %+v, want %+v", tt.name, *summary, tt.want)
+		}
+	}
+}
+
+func TestPreviewDiffInvalid(t *testing.T) {
+	const schema = "UserID,int,id,INT\nUserName,nvarchar,name,VARCHAR(20)\n"
+	tests := []struct {
+		current string
+		flags   []string
+		want    string
+	}{
+		{"", nil, "current rows file is empty"},
+		{"id,name\n1\n", nil, "failed to read current rows file"},
+		{"id,name\n", []string{"-diff-key", "code"}, `-diff-key column "code" is not in the schema`},
+		{"name\n", nil, `-diff-key column "id" is not in the current rows file`},
+	}
+	for _, tt := range tests {
+		_, err := previewDiff(t, schema, "UserID,UserName\n1,a\n", tt.current, tt.flags...)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: error %v, want %q", tt.current, err, tt.want)
+		}
+	}
+}
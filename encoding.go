@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+const (
+	EncodingAuto     = "auto"
+	EncodingUTF8     = "utf-8"
+	EncodingShiftJIS = "shift_jis"
+)
+
+func validateEncoding(flagName, encoding string) error {
+	switch strings.ToLower(encoding) {
+	case EncodingAuto, EncodingUTF8, "utf8", EncodingShiftJIS, "sjis", "cp932":
+		return nil
+	}
+	return fmt.Errorf("invalid %s %q: must be %s, %s or %s", flagName, encoding, EncodingAuto, EncodingUTF8, EncodingShiftJIS)
+}
+
+// decodeText は encoding に従って data を UTF-8 に直し、BOM を取り除きます。
+// auto では、UTF-8 として正しくない内容を Shift-JIS(Windows の CP932)とみなします。
+// 日本語版 Windows の Excel で保存した CSV は Shift-JIS になることが多いためです。
+func decodeText(data []byte, encoding string) ([]byte, error) {
+	data = removeBOM(data)
+	switch strings.ToLower(encoding) {
+	case EncodingUTF8, "utf8":
+		return data, nil
+	case EncodingAuto:
+		if utf8.Valid(data) {
+			return data, nil
+		}
+	}
+
+	decoded, err := japanese.ShiftJIS.NewDecoder().Bytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode as Shift-JIS: %s", err)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// shiftJIS は s を Shift-JIS に符号化します。
+func shiftJIS(t *testing.T, s string) string {
+	t.Helper()
+	encoded, err := japanese.ShiftJIS.NewEncoder().String(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+func TestDecodeText(t *testing.T) {
+	const text = "顧客ID,int,customer_id,INT\n"
+	tests := []struct {
+		data     string
+		encoding string
+		want     string
+	}{
+		{text, EncodingAuto, text},
+		{"\ufeff" + text, EncodingAuto, text},
+		{text, EncodingUTF8, text},
+		{"\x83\x5c", EncodingShiftJIS, "ソ"}, // 2バイト目が \ の文字
+		{shiftJIS(t, text), EncodingAuto, text},
+		{shiftJIS(t, text), "SJIS", text},
+		{shiftJIS(t, text), "cp932", text},
+	}
+	for _, tt := range tests {
+		got, err := decodeText([]byte(tt.data), tt.encoding)
+		if err != nil {
+			t.Errorf("decodeText(%q, %s): %s", tt.data, tt.encoding, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("decodeText(%q, %s) = %q, want %q", tt.data, tt.encoding, got, tt.want)
+		}
+	}
+}
+
+func TestShiftJISSchema(t *testing.T) {
+	input := "顧客ID,氏名,備考\n1,山田 太郎,表示\n"
+	schema := "顧客ID,int,customer_id,INT\n氏名,nvarchar,氏名,VARCHAR(20)\n備考,nvarchar,備考,VARCHAR(10),trim:表\n"
+	tests := []struct {
+		name   string
+		schema string
+		flags  []string
+	}{
+		{"auto", shiftJIS(t, schema), nil},
+		{"shift_jis", shiftJIS(t, schema), []string{"-schema-encoding", "shift_jis"}},
+		{"utf-8", schema, []string{"-schema-encoding", "utf-8"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{"input.csv": input, "schema.csv": tt.schema}
+			if err := runConvert(t, files, append(tt.flags, "t", "input.csv", "schema.csv")...); err != nil {
+				t.Fatal(err)
+			}
+			want := "INSERT INTO `t` (`customer_id`, `氏名`, `備考`)\nVALUES\n('1', '山田 太郎', '示');\n"
+			if got := readOutput(t, "t.SQL"); got != want {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestSchemaEncodingUTF8KeepsBytes(t *testing.T) {
+	// utf-8 と指定すると Shift-JIS の内容も読み替えない
+	data := shiftJIS(t, "顧客ID")
+	got, err := decodeText([]byte(data), EncodingUTF8)
+	if err != nil || string(got) != data {
+		t.Errorf("decodeText = %q, %v; want the bytes unchanged", got, err)
+	}
+	if _, err := ParseArgs([]string{"convert", "-schema-encoding", "euc-jp", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted -schema-encoding euc-jp")
+	}
+}
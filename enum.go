@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// convertBoolToEnum は SQL Server の 0/1 を ENUM の1番目/2番目のメンバーに対応させます。
+// bool-enum 変換で既にメンバー名になっている値はそのまま検証します。
+func convertBoolToEnum(value string, destType DataType) (Value, error) {
+	members := destType.Members()
+
+	switch {
+	case value == "0" && len(members) >= 1:
+		return Value{Text: members[0]}, nil
+	case value == "1" && len(members) >= 2:
+		return Value{Text: members[1]}, nil
+	}
+
+	if member, ok := findMember(value, members); ok {
+		return Value{Text: member}, nil
+	}
+	return Value{Text: value}, fmt.Errorf("%q is not a member of ENUM(%s)", value, strings.Join(destType.Args, ","))
+}
+
+// findMember は MySQL と同じく大文字小文字を区別せずにメンバーを探し、定義どおりの表記を返します。
+func findMember(value string, members []string) (string, bool) {
+	for _, member := range members {
+		if strings.EqualFold(value, member) {
+			return member, true
+		}
+	}
+	return "", false
+}
+
+// checkBoolEnum は bool-enum 変換で指定したメンバーが転送先の ENUM に存在するか確認します。
+func checkBoolEnum(column Schema) error {
+	destType := ParseDataType(column.DataTypeTo)
+	for _, transform := range column.Transforms {
+		if transform.Name != "bool-enum" || destType.Name != "ENUM" {
+			continue
+		}
+		for _, member := range strings.Split(transform.Arg, ",") {
+			if _, ok := findMember(member, destType.Members()); !ok {
+				return fmt.Errorf("bool-enum member %q is not in %s", member, column.DataTypeTo)
+			}
+		}
+	}
+	return nil
+}
+
+// convertSet はカンマ区切りの値を SET('a','b','c') のメンバーと照合し、定義どおりの表記で連結し直します。
+func convertSet(value string, destType DataType) (Value, error) {
+	if value == "" {
+		return Value{Text: value}, nil
+	}
+
+	members := destType.Members()
+	var elements, invalid []string
+	for _, element := range strings.Split(value, ",") {
+		element = strings.TrimSpace(element)
+		member, ok := findMember(element, members)
+		if !ok {
+			invalid = append(invalid, element)
+			continue
+		}
+		elements = append(elements, member)
+	}
+
+	if len(invalid) > 0 {
+		return Value{Text: value}, fmt.Errorf("%q contains elements that are not members of SET(%s): %s", value, strings.Join(destType.Args, ","), strings.Join(invalid, ", "))
+	}
+	return Value{Text: strings.Join(elements, ",")}, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertBoolToEnum(t *testing.T) {
+	tests := []struct {
+		value    string
+		destType string
+		want     string
+		wantErr  bool
+	}{
+		{"0", "ENUM('no','yes')", "no", false},
+		{"1", "ENUM('no','yes')", "yes", false},
+		{"yes", "ENUM('no','yes')", "yes", false},
+		{"YES", "ENUM('no','yes')", "yes", false}, // 定義どおりの表記にする
+		{"2", "ENUM('no','yes')", "2", true},
+		{"1", "ENUM('only')", "1", true},
+		{"0", "ENUM('it''s off','on')", "it's off", false},
+	}
+	for _, tt := range tests {
+		got, err := convertBoolToEnum(tt.value, ParseDataType(tt.destType))
+		if got.Text != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("convertBoolToEnum(%q, %s) = %q, %v; want %q, error %v", tt.value, tt.destType, got.Text, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestBoolEnumTransform(t *testing.T) {
+	// bool-enum で 0/1 に対応させるメンバーを ENUM の並び順と変えられる
+	schema := "active,int,active,\"ENUM('yes','no')\",\"bool-enum:no,yes\"\n"
+	sql, report, err := generate(t, schema, "active\n0\n1\n", testOptions(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sql, "('no'),\n('yes');") {
+		t.Errorf("output:\n%s", sql)
+	}
+	if len(report.Warnings) > 0 {
+		t.Errorf("warnings: %v", report.Warnings)
+	}
+}
+
+func TestBoolEnumUnknownMember(t *testing.T) {
+	_, err := parseSchema([][]string{{"active", "int", "active", "ENUM('yes','no')", "bool-enum:off,on"}}, Options{})
+	if err == nil {
+		t.Error("parseSchema accepted bool-enum members missing from the ENUM")
+	}
+}
+
+func TestConvertSet(t *testing.T) {
+	destType := ParseDataType("SET('read','write','admin')")
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"read,write", "read,write", false},
+		{"admin", "admin", false},
+		{"READ, Write", "read,write", false}, // 定義どおりの表記に揃える
+		{"", "", false},
+		{"read,delete", "read,delete", true},
+		{"read,,write", "read,,write", true},
+	}
+	for _, tt := range tests {
+		got, err := convertSet(tt.value, destType)
+		if got.Text != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("convertSet(%q) = %q, %v; want %q, error %v", tt.value, got.Text, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSetColumn(t *testing.T) {
+	schema := "perms,nvarchar,perms,\"SET('read','write')\"\n"
+	sql, report, err := generate(t, schema, "perms\n\"read,write\"\n\"read,exec\"\n", testOptions(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sql, "('read,write'),\n('read,exec');") {
+		t.Errorf("output:\n%s", sql)
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0].Row != 2 || !strings.Contains(report.Warnings[0].Message, "exec") {
+		t.Errorf("warnings = %v, want one for row 2 naming exec", report.Warnings)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ColumnExpression はスキーマの ColumnFrom を "=" で始めた場合の連結式です。
+// カラム名と '...' で囲んだ文字列リテラルを + でつなげる形だけをサポートします。
+// ROWNUM はヘッダーを除いた入力の行番号(1始まり)で、=ROWNUM だけの式は数値として出力します。
+//
+//	=first + ' ' + last
+//	=ROWNUM
+type ColumnExpression []expressionTerm
+
+type expressionTerm struct {
+	literal   string
+	column    string
+	isLiteral bool
+	isRowNum  bool
+}
+
+const rowNumberTerm = "ROWNUM"
+
+func isColumnExpression(columnFrom string) bool {
+	return strings.HasPrefix(columnFrom, "=")
+}
+
+func ParseColumnExpression(expr string) (ColumnExpression, error) {
+	source := strings.TrimPrefix(expr, "=")
+
+	var result ColumnExpression
+	var term strings.Builder
+	inQuote := false
+	for i := 0; i < len(source); i++ {
+		c := source[i]
+		switch {
+		case c == '\'' && inQuote && i+1 < len(source) && source[i+1] == '\'':
+			term.WriteString("''")
+			i++
+		case c == '\'':
+			inQuote = !inQuote
+			term.WriteByte(c)
+		case c == '+' && !inQuote:
+			parsed, err := parseExpressionTerm(term.String())
+			if err != nil {
+				return nil, fmt.Errorf("invalid expression %q: %s", expr, err)
+			}
+			result = append(result, parsed)
+			term.Reset()
+		default:
+			term.WriteByte(c)
+		}
+	}
+	if inQuote {
+		return nil, fmt.Errorf("invalid expression %q: unterminated string literal", expr)
+	}
+
+	parsed, err := parseExpressionTerm(term.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %s", expr, err)
+	}
+	return append(result, parsed), nil
+}
+
+func parseExpressionTerm(term string) (expressionTerm, error) {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return expressionTerm{}, fmt.Errorf("empty term")
+	}
+
+	if strings.HasPrefix(term, "'") {
+		if len(term) < 2 || !strings.HasSuffix(term, "'") {
+			return expressionTerm{}, fmt.Errorf("malformed string literal %s", term)
+		}
+		literal := strings.ReplaceAll(term[1:len(term)-1], "''", "'")
+		return expressionTerm{literal: literal, isLiteral: true}, nil
+	}
+
+	if strings.EqualFold(term, rowNumberTerm) {
+		return expressionTerm{isRowNum: true}, nil
+	}
+
+	return expressionTerm{column: term}, nil
+}
+
+// IsRowNumber は式が ROWNUM だけからなるかどうかを返します。
+func (e ColumnExpression) IsRowNumber() bool {
+	return len(e) == 1 && e[0].isRowNum
+}
+
+// CheckExpressionColumns は式が参照するカラムがすべて入力のヘッダーにあることを確かめます。
+// Evaluate はないカラムを空文字列として扱うので、綴りの誤りに気づけるよう先に断ります。
+func CheckExpressionColumns(schema []Schema, headerIndexMap map[string]int) error {
+	for _, column := range schema {
+		for _, term := range column.Expression {
+			if term.isLiteral || term.isRowNum {
+				continue
+			}
+			if _, ok := headerIndexMap[term.column]; !ok {
+				return fmt.Errorf("expression %s for column %s refers to %s, which is not in the input header", column.ColumnFrom, column.ColumnTo, term.column)
+			}
+		}
+	}
+	return nil
+}
+
+func (e ColumnExpression) Evaluate(rowNumber int, row []string, headerIndexMap map[string]int) string {
+	var value strings.Builder
+	for _, term := range e {
+		if term.isLiteral {
+			value.WriteString(term.literal)
+			continue
+		}
+		if term.isRowNum {
+			value.WriteString(strconv.Itoa(rowNumber))
+			continue
+		}
+		if index, ok := headerIndexMap[term.column]; ok && index < len(row) {
+			value.WriteString(row[index])
+		}
+	}
+	return value.String()
+}
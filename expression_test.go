@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestColumnExpressionEvaluate(t *testing.T) {
+	headerIndexMap := map[string]int{"first": 0, "last": 1}
+	row := []string{"Taro", "Yamada"}
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"=first + ' ' + last", "Taro Yamada"},
+		{"=last+first", "YamadaTaro"},
+		{"=last + ', ' + first", "Yamada, Taro"},
+		{"='Mr. ' + last", "Mr. Yamada"},
+		{"=first + ' O''Neil'", "Taro O'Neil"},
+		{"=first + ' + ' + last", "Taro + Yamada"},
+	}
+	for _, tt := range tests {
+		expression, err := ParseColumnExpression(tt.expr)
+		if err != nil {
+			t.Errorf("ParseColumnExpression(%q): %s", tt.expr, err)
+			continue
+		}
+		if got := expression.Evaluate(1, row, headerIndexMap); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestColumnExpressionInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"=first + ",
+		"=+ last",
+		"=first + 'unterminated",
+		"=first + 'a'b",
+	} {
+		if _, err := ParseColumnExpression(expr); err == nil {
+			t.Errorf("ParseColumnExpression(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestConcatColumns(t *testing.T) {
+	schema := "=first + ' ' + last,nvarchar,full_name,VARCHAR(50)\n"
+	sql, _, err := generate(t, schema, "first,last\nTaro,Yamada\nHanako,Sato\n", testOptions(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"(`full_name`)", "('Taro Yamada')", "('Hanako Sato')"} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("output does not contain %s:\n%s", want, sql)
+		}
+	}
+}
+
+func TestCheckExpressionColumns(t *testing.T) {
+	options := testOptions(t)
+	headerIndexMap := map[string]int{"first": 0, "last": 1}
+	tests := []struct {
+		schema  string
+		wantErr bool
+	}{
+		{"=first + ' ' + last,nvarchar,name,VARCHAR(50)\n", false},
+		{"=first + ' ' + lsat,nvarchar,name,VARCHAR(50)\n", true},
+		{"first,nvarchar,name,VARCHAR(50)\n", false},
+	}
+	for _, tt := range tests {
+		err := CheckExpressionColumns(testSchema(t, tt.schema, options), headerIndexMap)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckExpressionColumns(%q) = %v, want error %v", tt.schema, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRowNumberExpression(t *testing.T) {
+	const schema = "=ROWNUM,int,seq,INT\n=first + '-' + ROWNUM,nvarchar,code,VARCHAR(20)\n"
+	tests := []struct {
+		name  string
+		input string
+		flags []string
+		want  []string
+	}{
+		{"numbered", "first\na\nb\nc\n", nil, []string{"(1, 'a-1')", "(2, 'b-2')", "(3, 'c-3')"}},
+		// 飛ばした行や重複で除いた行も番号を使うので、入力の行と対応がとれる
+		{"rejected row", "first\na\nb,extra\nc\n", nil, []string{"(1, 'a-1')", "(3, 'c-3')"}},
+		{"dedupe", "first\na\na\nc\n", []string{"-dedupe-key", "code"}, []string{"(1, 'a-1')", "(2, 'a-2')", "(3, 'c-3')"}},
+		{"quote all", "first\na\n", []string{"-quote-all"}, []string{"('1', 'a-1')"}},
+	}
+	for _, tt := range tests {
+		sql, _, err := generate(t, schema, tt.input, testOptions(t, tt.flags...))
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		if got := strings.Count(sql, "("); got != len(tt.want)+1 {
+			t.Errorf("%s: %d tuples, want %d:\n%s", tt.name, got-1, len(tt.want), sql)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(sql, want) {
+				t.Errorf("%s: output does not contain %s:\n%s", tt.name, want, sql)
+			}
+		}
+	}
+}
+
+func TestRowNumberAcrossSplitFiles(t *testing.T) {
+	files := map[string]string{"input.csv": "first\na\nb\nc\n", "schema.csv": "=ROWNUM,int,seq,INT\nfirst,nvarchar,first,VARCHAR(5)\n"}
+	if err := runConvert(t, files, "-split-rows", "2", "t", "input.csv", "schema.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if sql := readOutput(t, "t_002.SQL"); !strings.Contains(sql, "(3, 'c')") {
+		t.Errorf("the second file restarts numbering:\n%s", sql)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// SQL Server のエクスポートが末尾に付ける "(1234 rows affected)" の行
+const rowsAffectedPattern = `^\(\d+ rows? affected\)$`
+
+// footerReader は入力の末尾にあるフッター行を CSV として読まれる前に取り除きます。
+// どの行が末尾かはファイルの終わりまで読まないとわからないため、常に末尾候補の行を
+// 手元に残し、それより前の行だけを渡します。
+type footerReader struct {
+	lines   *bufio.Reader
+	skip    int
+	pattern *regexp.Regexp
+	pending []string
+	held    int // pending のうち空でない行の数
+	buf     string
+	eof     bool
+}
+
+func newFooterReader(reader io.Reader, skip int, pattern string) (io.Reader, error) {
+	if skip == 0 && pattern == "" {
+		return reader, nil
+	}
+
+	footer := &footerReader{lines: bufio.NewReader(reader), skip: skip}
+	if pattern != "" {
+		if pattern == "rows-affected" {
+			pattern = rowsAffectedPattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -footer-pattern: %s", err)
+		}
+		footer.pattern = re
+	}
+	return footer, nil
+}
+
+// release は末尾候補でなくなった行を pending から buf に移します。末尾の -skip-footer 行に加え、
+// その前で -footer-pattern に一致する行が続いている間は、何行でもすべて手元に残します。
+// 行数は空でない行で数え、フッターの前後の空行は pending に残すので、"(2 rows affected)" の後に
+// 空行が続いてもフッターを見失いません。
+func (r *footerReader) release() {
+	if r.held <= r.skip {
+		return
+	}
+	if r.pattern == nil {
+		for r.held > r.skip {
+			if strings.TrimSpace(r.pending[0]) != "" {
+				r.held--
+			}
+			r.buf += r.pending[0]
+			r.pending = r.pending[1:]
+		}
+		return
+	}
+
+	// 末尾から skip+1 番目の空でない行は、いま -skip-footer の行から外れてパターンの候補に入った行。
+	// それより前の候補はすべてパターンに一致しているので、この行が一致しなければそこまでを渡す
+	i := len(r.pending) - 1
+	for seen := 0; ; i-- {
+		if strings.TrimSpace(r.pending[i]) != "" {
+			if seen++; seen == r.skip+1 {
+				break
+			}
+		}
+	}
+	if r.pattern.MatchString(strings.TrimSpace(r.pending[i])) {
+		return
+	}
+	r.buf += strings.Join(r.pending[:i+1], "")
+	r.pending = r.pending[i+1:]
+	r.held = r.skip
+}
+
+func (r *footerReader) Read(p []byte) (int, error) {
+	for r.buf == "" {
+		if r.eof {
+			return 0, io.EOF
+		}
+
+		line, err := r.lines.ReadString('\n')
+		if line != "" {
+			r.pending = append(r.pending, line)
+			if strings.TrimSpace(line) != "" {
+				r.held++
+			}
+		}
+		if err == io.EOF {
+			r.eof = true
+			r.buf = strings.Join(r.dropFooter(), "")
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		r.release()
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// dropFooter はファイルの終わりに達した時点で、末尾の -skip-footer 行と
+// -footer-pattern に一致する行を除いた残りを返します。CSV として読むときと同じく、
+// 空行は -skip-footer の行数に数えません。
+func (r *footerReader) dropFooter() []string {
+	lines := r.pending
+	r.pending, r.held = nil, 0
+
+	for skipped := 0; len(lines) > 0; lines = lines[:len(lines)-1] {
+		if strings.TrimSpace(lines[len(lines)-1]) != "" {
+			if skipped == r.skip {
+				break
+			}
+			skipped++
+		}
+	}
+
+	if r.pattern != nil {
+		for len(lines) > 0 {
+			last := strings.TrimSpace(lines[len(lines)-1])
+			if last != "" && !r.pattern.MatchString(last) {
+				break
+			}
+			lines = lines[:len(lines)-1]
+		}
+	}
+	return lines
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFooterReader(t *testing.T) {
+	data := "id,v\n1,a\n2,b\n"
+	tests := []struct {
+		name    string
+		input   string
+		skip    int
+		pattern string
+		want    string
+	}{
+		{"rows affected", data + "\n(2 rows affected)\n", 0, "rows-affected", data},
+		{"rows affected with trailing blank lines", data + "\n(2 rows affected)\n\n\n", 0, "rows-affected", data},
+		{"one row affected without newline", "id,v\n1,a\n(1 row affected)", 0, "rows-affected", "id,v\n1,a\n"},
+		{"no footer", data, 0, "rows-affected", data},
+		{"custom pattern", data + "-- end of export\n", 0, "^-- ", data},
+		{"skip lines", data + "Total: 2\nExported by sqlcmd\n", 2, "", data},
+		{"skip ignores blank lines", data + "Total: 2\n\n", 1, "", data},
+		{"skip and pattern", data + "(2 rows affected)\n\nCompletion time: 2023-01-01\n", 1, "rows-affected", data},
+		{"pattern only at the end", "id,v\n(1 rows affected)\n2,b\n", 0, "rows-affected", "id,v\n(1 rows affected)\n2,b\n"},
+		{"everything skipped", "id,v\n", 3, "", ""},
+		// パターンに一致する行が末尾に続く限り、何行でも取り除く
+		{"two pattern lines", data + "-- end of export\n-- exported by sqlcmd\n", 0, "^-- ", data},
+		{"pattern lines with blank lines", data + "-- a\n\n-- b\n-- c\n\n", 0, "^-- ", data},
+		{"pattern lines before data are kept", "id,v\n-- a\n-- b\n2,b\n-- c\n", 0, "^-- ", "id,v\n-- a\n-- b\n2,b\n"},
+		{"skip after pattern lines", data + "-- a\n-- b\nTotal: 2\n", 1, "^-- ", data},
+		{"every line matches", "-- a\n-- b\n", 0, "^-- ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1バイトずつ読ませても、末尾の行をまとめて判断できることを確かめる
+			reader, err := newFooterReader(iotest.OneByteReader(strings.NewReader(tt.input)), tt.skip, tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(iotest.OneByteReader(reader))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFooterPatternLines(t *testing.T) {
+	files := map[string]string{
+		"input.csv":  "id,name\n1,a\n2,b\n-- exported by sqlcmd\n-- 2 rows\n",
+		"schema.csv": "id,int,id,INT\nname,nvarchar,name,VARCHAR(10)\n",
+	}
+	if err := runConvert(t, files, "-footer-pattern", "^-- ", "-write-rejected", "t", "input.csv", "schema.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if sql := readOutput(t, "t.SQL"); strings.Count(sql, "('") != 2 || strings.Contains(sql, "--") {
+		t.Errorf("got\n%s", sql)
+	}
+	if _, err := os.Stat("t" + rejectedFileExtension); err == nil {
+		t.Error("a footer line was rejected as data")
+	}
+}
+
+func TestFooterPatternInvalid(t *testing.T) {
+	if _, err := newFooterReader(strings.NewReader(""), 0, "(unclosed"); err == nil {
+		t.Error("newFooterReader accepted an invalid pattern")
+	}
+}
+
+func TestRowsAffectedFooter(t *testing.T) {
+	files := map[string]string{
+		"input.csv":  "id,name\n1,a\n2,b\n\n(2 rows affected)\n\n",
+		"schema.csv": "id,int,id,INT\nname,nvarchar,name,VARCHAR(10)\n",
+	}
+	if err := runConvert(t, files, "-footer-pattern", "rows-affected", "t", "input.csv", "schema.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if sql := readOutput(t, "t.SQL"); !strings.HasSuffix(sql, "VALUES\n('1', 'a'),\n('2', 'b');\n") {
+		t.Errorf("output:\n%s", sql)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// maxReportedOrphans は -validate-foreign-keys で1件ずつ表示する外部キー違反の行の上限です。
+const maxReportedOrphans = 100
+
+// ForeignKeyCheck は -validate-foreign-keys の column=file の1つ分で、file は親テーブルの既存のキーを
+// ヘッダー付きで書き出した CSV です。キーは先頭のカラムから読みます。
+type ForeignKeyCheck struct {
+	Column   string
+	FileName string
+	index    int
+	keys     map[string]struct{}
+}
+
+// Orphan は親テーブルにないキーを参照している行です。
+type Orphan struct {
+	Row    int
+	Column string
+	Value  string
+	File   string
+}
+
+func (o Orphan) String() string {
+	return fmt.Sprintf("row %d, column %s: %q is not in %s", o.Row, o.Column, o.Value, o.File)
+}
+
+// ForeignKeySummary は -validate-foreign-keys の結果で、Orphans には先頭の maxReportedOrphans 件だけを入れます。
+// Orphans は入力の行の順で、同じ行の中ではスキーマのカラムの順ではなく -validate-foreign-keys を指定した順に並びます。
+type ForeignKeySummary struct {
+	OrphanRows   int
+	OrphanValues int
+	Orphans      []Orphan
+}
+
+func parseForeignKeyChecks(specs []string, schema []Schema) ([]*ForeignKeyCheck, error) {
+	var checks []*ForeignKeyCheck
+	for _, spec := range specs {
+		column, fileName, ok := strings.Cut(spec, "=")
+		if !ok || column == "" || fileName == "" {
+			return nil, fmt.Errorf("invalid -validate-foreign-keys %q: expected column=file", spec)
+		}
+		check := &ForeignKeyCheck{Column: column, FileName: fileName, index: -1}
+		for i, c := range schema {
+			if c.ColumnTo == column {
+				check.index = i
+				break
+			}
+		}
+		if check.index < 0 {
+			return nil, fmt.Errorf("-validate-foreign-keys column %q is not in the schema", column)
+		}
+		if err := check.readKeys(); err != nil {
+			return nil, err
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+func (c *ForeignKeyCheck) readKeys() error {
+	file, err := os.Open(c.FileName)
+	if err != nil {
+		return fmt.Errorf("failed to open parent keys file: %s", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to read parent keys file %s: %s", c.FileName, err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("parent keys file %s is empty", c.FileName)
+	}
+
+	c.keys = make(map[string]struct{}, len(records)-1)
+	for _, record := range records[1:] {
+		if len(record) > 0 {
+			c.keys[record[0]] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// ValidateForeignKeys は SQL を書き出さずに、変換後の外部キーのカラムの値が親テーブルの
+// キーにあるか確かめます。NULL と空の値は制約に違反しないので数えません。
+func ValidateForeignKeys(schema []Schema, headerIndexMap map[string]int, inputReader *csv.Reader, options Options) (*ForeignKeySummary, *Report, error) {
+	report := &Report{}
+	checks, err := parseForeignKeyChecks(options.ValidateForeignKeys, schema)
+	if err != nil {
+		return nil, report, err
+	}
+
+	converter, err := newRowConverter(schema, headerIndexMap, options, report)
+	if err != nil {
+		return nil, report, err
+	}
+
+	summary := &ForeignKeySummary{}
+	err = converter.each(inputReader, func(rowNumber int, values []Value) error {
+		orphan := false
+		for _, check := range checks {
+			value := values[check.index]
+			if value == nullValue || value.Text == "" {
+				continue
+			}
+			if _, ok := check.keys[value.Text]; ok {
+				continue
+			}
+			orphan = true
+			summary.OrphanValues++
+			if len(summary.Orphans) < maxReportedOrphans {
+				summary.Orphans = append(summary.Orphans, Orphan{Row: rowNumber, Column: check.Column, Value: value.Text, File: check.FileName})
+			}
+		}
+		if orphan {
+			summary.OrphanRows++
+		}
+		report.Rows++
+		return nil
+	})
+	if err != nil {
+		return nil, report, err
+	}
+	return summary, report, nil
+}
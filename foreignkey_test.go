@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// parentKeys は親テーブルのキーのファイルで、column=file の column と file の中身です。
+type parentKeys struct {
+	column  string
+	content string
+}
+
+// validateForeignKeys は parents の各ファイルを一時ディレクトリに書き、parents の順に column=file を
+// 指定して ValidateForeignKeys を実行します。
+func validateForeignKeys(t *testing.T, schemaCSV, inputCSV string, parents ...parentKeys) (*ForeignKeySummary, error) {
+	t.Helper()
+	dir := t.TempDir()
+	var flags []string
+	for _, parent := range parents {
+		fileName := filepath.Join(dir, parent.column+".csv")
+		if err := os.WriteFile(fileName, []byte(parent.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		flags = append(flags, "-validate-foreign-keys", parent.column+"="+fileName)
+	}
+	options := testOptions(t, flags...)
+	schema := testSchema(t, schemaCSV, options)
+	reader := csv.NewReader(strings.NewReader(inputCSV))
+	headers, err := ParseHeaders(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, _, err := ValidateForeignKeys(schema, MapHeadersToSchema(headers, schema), reader, options)
+	return summary, err
+}
+
+func TestValidateForeignKeys(t *testing.T) {
+	const schema = "id,int,id,INT\ncustomer,int,customer_id,INT\nitem,nvarchar,item_code,VARCHAR(10),upper\n"
+	const input = "id,customer,item\n1,10,a1\n2,11,b2\n3,,a1\n4,12,zz\n"
+	customers := parentKeys{"customer_id", "id\n10\n11\n"}
+	items := parentKeys{"item_code", "code,name\nA1,apple\nB2,banana\n"}
+	// 同じ行の違反は -validate-foreign-keys を指定した順に並ぶ
+	tests := []struct {
+		parents []parentKeys
+		want    string
+	}{
+		{[]parentKeys{customers, items}, "4:customer_id=12,4:item_code=ZZ"},
+		{[]parentKeys{items, customers}, "4:item_code=ZZ,4:customer_id=12"},
+	}
+	for _, tt := range tests {
+		summary, err := validateForeignKeys(t, schema, input, tt.parents...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 空の値は数えず、値は upper などの変換の後で比べる
+		if summary.OrphanRows != 1 || summary.OrphanValues != 2 {
+			t.Errorf("orphan rows %d, values %d; want 1, 2", summary.OrphanRows, summary.OrphanValues)
+		}
+		var orphans []string
+		for _, orphan := range summary.Orphans {
+			orphans = append(orphans, fmt.Sprintf("%d:%s=%s", orphan.Row, orphan.Column, orphan.Value))
+		}
+		if got := strings.Join(orphans, ","); got != tt.want {
+			t.Errorf("orphans %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestValidateForeignKeysLimit(t *testing.T) {
+	var input strings.Builder
+	input.WriteString("customer\n")
+	for i := 0; i < maxReportedOrphans+5; i++ {
+		fmt.Fprintf(&input, "%d\n", i+100)
+	}
+	summary, err := validateForeignKeys(t, "customer,int,customer_id,INT\n", input.String(), parentKeys{"customer_id", "id\n1\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.OrphanRows != maxReportedOrphans+5 || len(summary.Orphans) != maxReportedOrphans {
+		t.Errorf("orphan rows %d, reported %d", summary.OrphanRows, len(summary.Orphans))
+	}
+}
+
+func TestValidateForeignKeysErrors(t *testing.T) {
+	const schema = "customer,int,customer_id,INT\n"
+	if _, err := validateForeignKeys(t, schema, "customer\n1\n", parentKeys{"customer", "id\n1\n"}); err == nil || !strings.Contains(err.Error(), "is not in the schema") {
+		t.Errorf("error %v, want an unknown column", err)
+	}
+	if _, err := validateForeignKeys(t, schema, "customer\n1\n", parentKeys{"customer_id", ""}); err == nil || !strings.Contains(err.Error(), "is empty") {
+		t.Errorf("error %v, want an empty parent keys file", err)
+	}
+	if _, err := parseForeignKeyChecks([]string{"customer_id"}, nil); err == nil {
+		t.Error("parseForeignKeyChecks accepted a spec without a file")
+	}
+}
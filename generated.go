@@ -0,0 +1,38 @@
+package main
+
+import "strings"
+
+// parseColumnList はカンマ区切りのカラム名の一覧を、大文字小文字を区別しない集合にします。
+func parseColumnList(list string) map[string]bool {
+	columns := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			columns[strings.ToLower(name)] = true
+		}
+	}
+	return columns
+}
+
+// ExcludeGeneratedColumns は -generated-columns のカラムをスキーマから除きます。
+// MySQL の生成カラムには値を INSERT できないので、カラムの一覧と値の両方から外します。
+func ExcludeGeneratedColumns(schema []Schema, generated map[string]bool) []Schema {
+	result := make([]Schema, 0, len(schema))
+	for _, column := range schema {
+		if !generated[strings.ToLower(column.ColumnTo)] {
+			result = append(result, column)
+		}
+	}
+	return result
+}
+
+// excludeGeneratedTableColumns は -table-columns のカラムから生成カラムを除き、
+// スキーマにないカラムとして警告されないようにします。
+func excludeGeneratedTableColumns(tableColumns []string, generated map[string]bool) []string {
+	result := make([]string, 0, len(tableColumns))
+	for _, name := range tableColumns {
+		if !generated[strings.ToLower(name)] {
+			result = append(result, name)
+		}
+	}
+	return result
+}
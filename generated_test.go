@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseColumnList(t *testing.T) {
+	got := parseColumnList(" Total , tax,,")
+	if len(got) != 2 || !got["total"] || !got["tax"] {
+		t.Errorf("parseColumnList = %v", got)
+	}
+	if got := parseColumnList(""); len(got) != 0 {
+		t.Errorf("parseColumnList(\"\") = %v, want empty", got)
+	}
+}
+
+func TestExcludeGeneratedColumns(t *testing.T) {
+	schema := testSchema(t, "id,int,id,INT\nprice,int,price,INT\ntotal,int,Total,INT\n", Options{})
+	got := ExcludeGeneratedColumns(schema, parseColumnList("total"))
+	if len(got) != 2 || got[0].ColumnTo != "id" || got[1].ColumnTo != "price" {
+		t.Errorf("ExcludeGeneratedColumns = %+v", got)
+	}
+	tableColumns := excludeGeneratedTableColumns([]string{"id", "price", "TOTAL"}, parseColumnList("total"))
+	if strings.Join(tableColumns, ",") != "id,price" {
+		t.Errorf("excludeGeneratedTableColumns = %q", tableColumns)
+	}
+}
+
+func TestGeneratedColumnsFile(t *testing.T) {
+	files := map[string]string{
+		"input.csv":  "id,price,total\n1,10,11\n",
+		"schema.csv": "id,int,id,INT\nprice,int,price,INT\ntotal,int,total,INT\n",
+	}
+	if err := runConvert(t, files, "-generated-columns", "total", "t", "input.csv", "schema.csv"); err != nil {
+		t.Fatal(err)
+	}
+	want := "INSERT INTO `t` (`id`, `price`)\nVALUES\n('1', '10');\n"
+	if got := readOutput(t, "t.SQL"); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
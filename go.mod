@@ -1,3 +1,5 @@
 module sqlserver-mysql
 
 go 1.21.6
+
+require golang.org/x/text v0.14.0
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// normalizeHeaders は SQL Server のエクスポートが付けるヘッダーの装飾を取り除き、スキーマの
+// ColumnFrom にそのまま書いた名前と一致させます。[Order Date] の角かっこと、dbo.Column や
+// [dbo].[Column] のスキーマ名を取り除きます。角かっこの中の . と ]] はカラム名の一部として扱います。
+func normalizeHeaders(headers []string) ([]string, error) {
+	normalized := make([]string, len(headers))
+	seen := make(map[string]string, len(headers))
+	for i, header := range headers {
+		name := normalizeHeader(header)
+		if previous, ok := seen[name]; ok {
+			return nil, fmt.Errorf("headers %q and %q both normalize to %q", previous, header, name)
+		}
+		seen[name] = header
+		normalized[i] = name
+	}
+	return normalized, nil
+}
+
+// normalizeHeader は . で区切った最後の部分を、角かっこを外して返します。
+func normalizeHeader(header string) string {
+	var part strings.Builder
+	bracketed := false
+	inBracket := false
+	for i := 0; i < len(header); i++ {
+		c := header[i]
+		switch {
+		case inBracket && c == ']' && i+1 < len(header) && header[i+1] == ']':
+			part.WriteByte(']')
+			i++
+		case inBracket && c == ']':
+			inBracket = false
+		case !inBracket && c == '[':
+			inBracket, bracketed = true, true
+		case !inBracket && c == '.':
+			part.Reset()
+			bracketed = false
+		default:
+			part.WriteByte(c)
+		}
+	}
+	if inBracket {
+		// 閉じていない角かっこは装飾ではないとみなし、元のヘッダーのまま使う
+		return header
+	}
+	if bracketed {
+		return part.String()
+	}
+	return strings.TrimSpace(part.String())
+}
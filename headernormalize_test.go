@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeHeader(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"OrderID", "OrderID"},
+		{"[Order Date]", "Order Date"},
+		{"dbo.Customer", "Customer"},
+		{"[dbo].[Customer Name]", "Customer Name"},
+		{"sales.dbo.Total", "Total"},
+		{"[Price.Tax]", "Price.Tax"}, // 角かっこの中の . はカラム名の一部
+		{"[a]]b]", "a]b"},            // ]] は ] 1文字
+		{"[ padded ]", " padded "},   // 角かっこの中の空白は残す
+		{" dbo. Name ", "Name"},
+		{"[unterminated", "[unterminated"},
+	}
+	for _, tt := range tests {
+		if got := normalizeHeader(tt.header); got != tt.want {
+			t.Errorf("normalizeHeader(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeHeaders(t *testing.T) {
+	got, err := normalizeHeaders([]string{"[dbo].[id]", "[Order Date]", "name"})
+	if err != nil || strings.Join(got, ",") != "id,Order Date,name" {
+		t.Errorf("normalizeHeaders = %q, %v", got, err)
+	}
+	if _, err := normalizeHeaders([]string{"[dbo].[id]", "id"}); err == nil || !strings.Contains(err.Error(), `both normalize to "id"`) {
+		t.Errorf("error %v, want a collision", err)
+	}
+}
+
+func TestHeaderNormalizeFile(t *testing.T) {
+	files := map[string]string{
+		"input.csv":  "[dbo].[OrderID],[Order Date]\n1,2023-01-02\n",
+		"schema.csv": "OrderID,int,order_id,INT\nOrder Date,date,order_date,DATE\n",
+	}
+	if err := runConvert(t, files, "-header-normalize", "t", "input.csv", "schema.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readOutput(t, "t.SQL"); !strings.Contains(got, "('1', '2023-01-02')") {
+		t.Errorf("got\n%s", got)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// schemaFileSuffix で終わるファイルは -input-dir の入力ではなく、同じ名前の入力のスキーマです(users.csv に対する users.schema.csv)。
+const schemaFileSuffix = ".schema.csv"
+
+// ConvertDir は -input-dir のディレクトリにあるすべての .csv を、ファイル名をテーブル名として変換します。
+// スキーマは name.schema.csv があればそれを、なければ引数で指定した共通のスキーマを使います。
+// 失敗したファイルがあっても残りのファイルの変換を続けます。
+func ConvertDir(args Args) error {
+	entries, err := os.ReadDir(args.InputDir)
+	if err != nil {
+		return fmt.Errorf("failed to read input directory: %s", err)
+	}
+
+	var inputFileNames []string
+	for _, entry := range entries {
+		name := entry.Name()
+		lower := strings.ToLower(name)
+		if entry.IsDir() || !strings.HasSuffix(lower, ".csv") || strings.HasSuffix(lower, schemaFileSuffix) {
+			continue
+		}
+		inputFileNames = append(inputFileNames, name)
+	}
+	sort.Strings(inputFileNames)
+	if len(inputFileNames) == 0 {
+		return fmt.Errorf("no .csv files in input directory %s", args.InputDir)
+	}
+
+	failed := 0
+	for _, name := range inputFileNames {
+		fileArgs := args
+		fileArgs.TableName = name[:len(name)-len(".csv")]
+		fileArgs.InputFileName = filepath.Join(args.InputDir, name)
+
+		schemaFileName := filepath.Join(args.InputDir, fileArgs.TableName+schemaFileSuffix)
+		if _, err := os.Stat(schemaFileName); err == nil {
+			fileArgs.SchemaFileName = schemaFileName
+		}
+
+		fmt.Printf("%s:\n", fileArgs.InputFileName)
+		if err := convertDirFile(fileArgs); err != nil {
+			fmt.Println(err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files in %s failed to convert", failed, len(inputFileNames), args.InputDir)
+	}
+	return nil
+}
+
+func convertDirFile(args Args) error {
+	if args.SchemaFileName == "" {
+		return fmt.Errorf("no schema: add %s or pass a shared schema file", args.TableName+schemaFileSuffix)
+	}
+	tableName := args.TableIdentifier(args.TableName)
+	if n := utf8.RuneCountInString(tableName); n > maxIdentifierLength {
+		return fmt.Errorf("table name %q is %d characters long, exceeding the MySQL limit of %d", tableName, n, maxIdentifierLength)
+	}
+	return ConvertFile(args)
+}
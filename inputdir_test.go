@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// runConvertDir は files を書いた一時ディレクトリで、-input-dir の変換を実行します。
+func runConvertDir(t *testing.T, files map[string]string, args ...string) error {
+	t.Helper()
+	writeFiles(t, files)
+	parsed, err := ParseArgs(append([]string{"convert"}, args...))
+	if err != nil {
+		return err
+	}
+	return ConvertDir(*parsed)
+}
+
+func TestConvertDir(t *testing.T) {
+	files := map[string]string{
+		"in/users.csv":         "id,name\n1,alice\n",
+		"in/orders.CSV":        "id,total\n10,2.5\n",
+		"in/orders.schema.csv": "id,int,order_id,INT\ntotal,decimal,total,\"DECIMAL(10,2)\"\n",
+		"in/notes.txt":         "not an input",
+		"shared.csv":           "id,int,id,INT\nname,nvarchar,name,VARCHAR(10)\n",
+	}
+	if err := runConvertDir(t, files, "-input-dir", "in", "-table-prefix", "stg_", "shared.csv"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		output string
+		want   string
+	}{
+		// テーブル名はファイル名から、スキーマは同じ名前の .schema.csv があればそれを使う
+		{"users.SQL", "INSERT INTO `stg_users` (`id`, `name`)\nVALUES\n('1', 'alice');\n"},
+		{"orders.SQL", "INSERT INTO `stg_orders` (`order_id`, `total`)\nVALUES\n('10', 2.50);\n"},
+	}
+	for _, tt := range tests {
+		if got := readOutput(t, tt.output); got != tt.want {
+			t.Errorf("%s:\n%s\nwant\n%s", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestConvertDirFailures(t *testing.T) {
+	files := map[string]string{
+		"in/a.csv":        "id\n1\n",
+		"in/b.csv":        "id\n2\n",
+		"in/b.schema.csv": "id,int,id,INT\n",
+	}
+	// 共通のスキーマがないので a は失敗するが、b の変換は続ける
+	err := runConvertDir(t, files, "-input-dir", "in")
+	if err == nil || err.Error() != "1 of 2 files in in failed to convert" {
+		t.Errorf("error %v", err)
+	}
+	if got := readOutput(t, "b.SQL"); !strings.Contains(got, "('2')") {
+		t.Errorf("b.SQL:\n%s", got)
+	}
+
+	if err := runConvertDir(t, map[string]string{"empty/readme.txt": ""}, "-input-dir", "empty"); err == nil || !strings.Contains(err.Error(), "no .csv files") {
+		t.Errorf("error %v, want no .csv files", err)
+	}
+}
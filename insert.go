@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// insertWriter は変換済みの行を受け取り、複数行 INSERT 文として書き出します。
+// 文の先頭は最初の行を受け取ったときに書くので、行がなければ何も出力しません。
+type insertWriter struct {
+	writer    io.Writer
+	parts     PartWriter // -split-rows でファイルを切り替える先。nil なら同じ出力に続けて書く
+	header    string
+	tupleOpen string
+	footer    string
+	closing   string // 最後の文の後に書く OPTIMIZE TABLE/ANALYZE TABLE
+	newline   string // -normalize-line-endings の改行
+	options   Options
+
+	partStarted    bool
+	tuples         int
+	statementBytes int
+	batch          int // -batch-markers で付ける文の通し番号
+	err            error
+}
+
+func newInsertWriter(writer io.Writer, tableName string, schema []Schema, options Options) (*insertWriter, error) {
+	columns := make([]string, 0, len(schema))
+	for _, column := range schema {
+		columns = append(columns, options.QuoteIdentifier(column.ColumnTo))
+	}
+
+	tupleOpen := "("
+	if options.ValuesSyntax == ValuesSyntaxRow {
+		tupleOpen = "ROW("
+	}
+
+	footer, err := onDuplicateKeyUpdate(schema, options)
+	if err != nil {
+		return nil, err
+	}
+
+	newline := lineEnding(options.LineEndings)
+	if options.ValuesOnly {
+		// 手書きの INSERT ... VALUES に続けて貼り付けられるよう、タプルとその間のカンマだけを書く
+		return &insertWriter{writer: writer, tupleOpen: tupleOpen, newline: newline, options: options}, nil
+	}
+
+	// -max-packet は書き出すバイト数で数えるので、文の改行は先に -normalize-line-endings に揃えておく
+	header := fmt.Sprintf("%s INTO %s (%s)\nVALUES\n", insertKeyword(options), options.QuoteIdentifier(options.TableIdentifier(tableName)), strings.Join(columns, ", "))
+	return &insertWriter{
+		writer:    writer,
+		header:    normalizeNewlines(header, newline),
+		tupleOpen: tupleOpen,
+		footer:    normalizeNewlines(footer, newline),
+		closing:   normalizeNewlines(maintenanceStatements(tableName, options), newline),
+		newline:   newline,
+		options:   options,
+	}, nil
+}
+
+func normalizeNewlines(str, newline string) string {
+	if newline == "\n" {
+		return str
+	}
+	return strings.ReplaceAll(str, "\n", newline)
+}
+
+// write は文の構造の部分を書き出し、改行を -normalize-line-endings に揃えます。
+func (s *insertWriter) write(str string) {
+	s.writeRaw(normalizeNewlines(str, s.newline))
+}
+
+// writeRaw は str をそのまま書き出します。改行を揃え済みの文の先頭や末尾と、
+// NO_BACKSLASH_ESCAPES では値の改行がリテラルにそのまま入るタプルに使います。
+func (s *insertWriter) writeRaw(str string) {
+	if s.err != nil {
+		return
+	}
+	_, s.err = io.WriteString(s.writer, str)
+}
+
+// add は1行分のタプルを書き出します。-max-packet 指定時は、タプルを足すと文全体
+// (INSERT の先頭、区切りのカンマ、ON DUPLICATE KEY UPDATE 句と末尾の ; を含む)が
+// 上限を超える場合に、先に今の文を閉じて新しい INSERT 文を始めます。
+func (s *insertWriter) add(rowNumber int, values []Value) error {
+	literals := make([]string, 0, len(values))
+	for _, value := range values {
+		literals = append(literals, value.SQL(s.options.noBackslashEscapes))
+	}
+	tuple := s.tupleOpen + strings.Join(literals, ", ") + ")"
+
+	closing := len(s.footer) + len(";")
+	if s.options.MaxPacket > 0 {
+		if size := len(s.header) + len(tuple) + closing; size > s.options.MaxPacket {
+			return fmt.Errorf("row %d makes a %d-byte INSERT statement on its own, larger than -max-packet %d", rowNumber, size, s.options.MaxPacket)
+		}
+		if s.tuples > 0 && s.statementBytes+len(","+s.newline)+len(tuple)+closing > s.options.MaxPacket {
+			s.end()
+		}
+	}
+
+	switch {
+	case !s.partStarted:
+		s.write(preamble(s.options))
+		s.partStarted = true
+		fallthrough
+	case s.tuples == 0:
+		if s.statementBytes > 0 {
+			s.write("\n") // 同じファイル内の前の文との区切り
+		}
+		s.batch++
+		if s.options.BatchMarkers {
+			s.write(fmt.Sprintf("-- batch %d start\n", s.batch))
+		}
+		s.writeRaw(s.header)
+		s.statementBytes = len(s.header)
+	default:
+		s.write(",\n")
+		s.statementBytes += len("," + s.newline)
+	}
+
+	s.writeRaw(tuple)
+	s.statementBytes += len(tuple)
+	s.tuples++
+	return s.err
+}
+
+// end は書きかけの INSERT 文を閉じます。
+// -batch-markers のコメントは文の外に書くので、-max-packet の計算には含めません。
+func (s *insertWriter) end() {
+	if s.tuples == 0 {
+		return
+	}
+	if !s.options.ValuesOnly {
+		s.writeRaw(s.footer + ";")
+	}
+	if s.options.BatchMarkers {
+		s.write(fmt.Sprintf("\n-- batch %d end", s.batch))
+	}
+	s.tuples = 0
+}
+
+// finish は文を閉じ、-trailing-newline に従ってファイルの末尾を書きます。
+func (s *insertWriter) finish() error {
+	if s.partStarted {
+		s.end()
+		if s.options.TrailingNewline {
+			s.write("\n")
+		}
+	}
+	s.partStarted = false
+	s.statementBytes = 0
+	return s.err
+}
+
+// close は最後の文を閉じ、-optimize/-analyze の文を続けて出力を終えます。
+// -split-rows でファイルが分かれる場合は、最後のファイルにだけ書きます。
+func (s *insertWriter) close() error {
+	if s.partStarted {
+		s.end()
+		s.writeRaw(s.closing)
+	}
+	return s.finish()
+}
+
+// nextPart は今のファイルを閉じ、以降の行を次のファイルに書き出します。
+func (s *insertWriter) nextPart() error {
+	if err := s.finish(); err != nil {
+		return err
+	}
+	if s.parts != nil {
+		s.err = s.parts.NextPart()
+	}
+	return s.err
+}
+
+// validateValuesOnlyOptions は -values-only と、INSERT 文やその前後の文を書くオプションの組み合わせを断ります。
+func validateValuesOnlyOptions(options Options) error {
+	if !options.ValuesOnly {
+		return nil
+	}
+	switch {
+	case options.LoadData || options.Prepared:
+		return fmt.Errorf("-values-only cannot be used with -load-data or -prepared")
+	case options.Upsert || options.InsertModifier != "":
+		return fmt.Errorf("-values-only cannot be used with -upsert or -insert-modifier")
+	case options.MaxPacket > 0 || options.BatchMarkers:
+		return fmt.Errorf("-values-only writes a single list of tuples and cannot be used with -max-packet or -batch-markers")
+	case options.Database != "" || options.SetNames != "" || options.SQLMode != "" || options.Analyze || options.Optimize:
+		return fmt.Errorf("-values-only cannot be used with -database, -set-names, -sql-mode, -analyze or -optimize")
+	}
+	return nil
+}
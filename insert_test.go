@@ -0,0 +1,217 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// checkGolden は got を testdata/name.golden と比べます。-update を付けると書き換えます。
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+const goldenSchema = `id,int,id,INT
+name,nvarchar,name,VARCHAR(20)
+price,decimal,price,"DECIMAL(10,2)"
+`
+
+const goldenInput = `id,name,price
+1,apple,1.5
+2,O'Brien,2.25E+1
+3,,0
+`
+
+func TestValuesSyntaxGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+	}{
+		{"values_standard", nil},
+		{"values_row", []string{"-values-syntax", "row"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _, err := generate(t, goldenSchema, goldenInput, testOptions(t, tt.flags...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name, sql)
+		})
+	}
+}
+
+func TestValuesSyntaxInvalid(t *testing.T) {
+	if _, err := ParseArgs([]string{"convert", "-values-syntax", "tuple", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted -values-syntax tuple")
+	}
+}
+
+func TestTrailingNewline(t *testing.T) {
+	files := map[string]string{
+		"input.csv":  "id\n1\n2\n3\n",
+		"schema.csv": "id,int,id,INT\n",
+	}
+	tests := []struct {
+		name    string
+		flags   []string
+		outputs []string
+	}{
+		{"file", nil, []string{"t.SQL"}},
+		{"split", []string{"-split-rows", "2"}, []string{"t_001.SQL", "t_002.SQL"}},
+		{"load data", []string{"-load-data"}, []string{"t.SQL"}},
+		{"optimize", []string{"-optimize"}, []string{"t.SQL"}},
+		{"batch markers", []string{"-batch-markers"}, []string{"t.SQL"}},
+	}
+	for _, tt := range tests {
+		for _, trailing := range []bool{true, false} {
+			name := tt.name + " without newline"
+			flags := append([]string{"-trailing-newline=false"}, tt.flags...)
+			if trailing {
+				name = tt.name + " with newline"
+				flags = tt.flags
+			}
+			t.Run(name, func(t *testing.T) {
+				if err := runConvert(t, files, append(flags, "t", "input.csv", "schema.csv")...); err != nil {
+					t.Fatal(err)
+				}
+				for _, output := range tt.outputs {
+					sql := readOutput(t, output)
+					if got := strings.HasSuffix(sql, "\n"); got != trailing {
+						t.Errorf("%s ends with a newline: %v, want %v\n%q", output, got, trailing, sql)
+					}
+					if strings.HasSuffix(sql, "\n\n") {
+						t.Errorf("%s ends with a blank line: %q", output, sql)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestMaxPacket(t *testing.T) {
+	const schema = "id,int,id,INT\nname,nvarchar,name,VARCHAR(100)\n"
+	// バックスラッシュとクォートはエスケープで2倍になるので、入力の長さでは数えられない
+	input := "id,name\n"
+	for i := 1; i <= 20; i++ {
+		input += fmt.Sprintf("%d,\"a\\b'c\"\"d\\\\%d\"\n", i, i)
+	}
+	tests := []struct {
+		flags   []string
+		newline string
+	}{
+		{nil, "\n"},
+		{[]string{"-normalize-line-endings", "crlf"}, "\r\n"},
+	}
+	for _, tt := range tests {
+		for _, limit := range []int{120, 200, 500} {
+			options := testOptions(t, append(tt.flags, "-max-packet", strconv.Itoa(limit))...)
+			sql, _, err := generate(t, schema, input, options)
+			if err != nil {
+				t.Fatalf("%q -max-packet %d: %s", tt.flags, limit, err)
+			}
+			statements := strings.Split(strings.TrimSuffix(sql, tt.newline), ";"+tt.newline)
+			if len(statements) < 2 {
+				t.Errorf("%q -max-packet %d: not split:\n%s", tt.flags, limit, sql)
+			}
+			for i, statement := range statements {
+				statement = strings.TrimSuffix(statement, ";") + ";"
+				if len(statement) > limit {
+					t.Errorf("%q -max-packet %d: statement %d is %d bytes:\n%s", tt.flags, limit, i+1, len(statement), statement)
+				}
+			}
+			if n := strings.Count(sql, `'a\\b\'c\"d\\\\`); n != 20 {
+				t.Errorf("%q -max-packet %d: %d rows written, want 20", tt.flags, limit, n)
+			}
+		}
+	}
+}
+
+func TestMaxPacketTooSmall(t *testing.T) {
+	options := testOptions(t, "-max-packet", "40")
+	_, _, err := generate(t, "name,nvarchar,name,VARCHAR(100)\n", "name\n"+strings.Repeat("\\", 30)+"\n", options)
+	if err == nil || !strings.Contains(err.Error(), "larger than -max-packet 40") {
+		t.Errorf("error %v, want a row larger than -max-packet", err)
+	}
+}
+
+func TestBatchMarkersGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+	}{
+		{"batch_markers", []string{"-batch-markers"}},
+		{"batch_markers_max_packet", []string{"-batch-markers", "-max-packet", "90"}},
+		{"batch_markers_crlf", []string{"-batch-markers", "-max-packet", "90", "-normalize-line-endings", "crlf"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _, err := generate(t, goldenSchema, goldenInput, testOptions(t, tt.flags...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name, sql)
+		})
+	}
+}
+
+func TestValuesOnlyGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+	}{
+		{"values_only", []string{"-values-only"}},
+		{"values_only_row", []string{"-values-only", "-values-syntax", "row"}},
+		{"values_only_quote_all", []string{"-values-only", "-quote-all"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _, err := generate(t, goldenSchema, goldenInput, testOptions(t, tt.flags...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(sql, "INSERT") || strings.Contains(sql, ";") {
+				t.Errorf("values-only output has an INSERT prefix or terminator:\n%s", sql)
+			}
+			checkGolden(t, tt.name, sql)
+		})
+	}
+}
+
+func TestValuesOnlyInvalid(t *testing.T) {
+	tests := [][]string{
+		{"-values-only", "-load-data"},
+		{"-values-only", "-prepared"},
+		{"-values-only", "-upsert"},
+		{"-values-only", "-insert-modifier", "IGNORE"},
+		{"-values-only", "-max-packet", "1024"},
+		{"-values-only", "-batch-markers"},
+		{"-values-only", "-database", "app"},
+		{"-values-only", "-set-names", "utf8mb4"},
+	}
+	for _, flags := range tests {
+		args := append(append([]string{"convert"}, flags...), "t", "input.csv", "schema.csv")
+		if _, err := ParseArgs(args); err == nil {
+			t.Errorf("ParseArgs accepted %q", flags)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// integerBits は MySQL の整数型のビット数を返します。
+func integerBits(name string) (int, bool) {
+	switch name {
+	case "TINYINT":
+		return 8, true
+	case "SMALLINT":
+		return 16, true
+	case "MEDIUMINT":
+		return 24, true
+	case "INT", "INTEGER":
+		return 32, true
+	case "BIGINT":
+		return 64, true
+	}
+	return 0, false
+}
+
+// checkIntegerRange は値が転送先の整数型(UNSIGNED を含む)の範囲に収まるか確認します。
+// SQL Server の tinyint は 0〜255 なので、符号付きの TINYINT では 128 以上があふれます。
+func checkIntegerRange(value string, destType DataType) error {
+	bits, ok := integerBits(destType.Name)
+	if !ok || value == "" {
+		return nil
+	}
+	value = strings.TrimSpace(value)
+
+	if destType.Unsigned {
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || n > 1<<uint(bits)-1 {
+			if isInteger(value) {
+				return fmt.Errorf("%s is out of range for %s UNSIGNED (0 to %d)", value, destType.Name, uint64(1<<uint(bits)-1))
+			}
+			return fmt.Errorf("%q is not a valid integer", value)
+		}
+		return nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	min, max := -int64(1)<<uint(bits-1), int64(1)<<uint(bits-1)-1
+	if err != nil || n < min || n > max {
+		if isInteger(value) {
+			return fmt.Errorf("%s is out of range for %s (%d to %d)", value, destType.Name, min, max)
+		}
+		return fmt.Errorf("%q is not a valid integer", value)
+	}
+	return nil
+}
+
+// convertInteger は範囲を確認した整数を返します。-bare-unsigned-bigint 指定時は、
+// BIGINT UNSIGNED に収まる値を 2^63 以上も含めて数値リテラルのまま出力します。
+func convertInteger(value string, destType DataType, options Options) (Value, error) {
+	if err := checkIntegerRange(value, destType); err != nil {
+		return Value{Text: value}, err
+	}
+	if options.BareUnsignedBigint && destType.Name == "BIGINT" && destType.Unsigned && value != "" {
+		n, _ := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		return Value{Text: strconv.FormatUint(n, 10), Kind: NumberValue}, nil
+	}
+	return Value{Text: value}, nil
+}
+
+func isInteger(value string) bool {
+	digits := strings.TrimPrefix(strings.TrimPrefix(value, "-"), "+")
+	if digits == "" {
+		return false
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckSchemaTypes は値を見なくてもわかる型の組み合わせの問題をスキーマから探します。
+func CheckSchemaTypes(schema []Schema) []Warning {
+	var warnings []Warning
+	for _, column := range schema {
+		destType := ParseDataType(column.DataTypeTo)
+		if sourceTypeName(column.DataTypeFrom) == "tinyint" && destType.Name == "TINYINT" && !destType.Unsigned {
+			warnings = append(warnings, Warning{
+				Column:  column.ColumnTo,
+				Message: "SQL Server tinyint ranges from 0 to 255; use TINYINT UNSIGNED to hold values above 127",
+			})
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckIntegerRange(t *testing.T) {
+	tests := []struct {
+		value    string
+		destType string
+		wantErr  string
+	}{
+		{"127", "TINYINT", ""},
+		{"128", "TINYINT", "128 is out of range for TINYINT (-128 to 127)"},
+		{"255", "TINYINT", "255 is out of range for TINYINT (-128 to 127)"},
+		{"-128", "TINYINT", ""},
+		{"128", "TINYINT UNSIGNED", ""},
+		{"255", "TINYINT(3) UNSIGNED", ""},
+		{"256", "TINYINT UNSIGNED", "256 is out of range for TINYINT UNSIGNED (0 to 255)"},
+		{"-1", "TINYINT UNSIGNED", "-1 is out of range for TINYINT UNSIGNED (0 to 255)"},
+		{"65535", "SMALLINT UNSIGNED", ""},
+		{"32768", "SMALLINT", "32768 is out of range for SMALLINT (-32768 to 32767)"},
+		{"2147483648", "INT", "2147483648 is out of range for INT (-2147483648 to 2147483647)"},
+		{"12a", "INT", `"12a" is not a valid integer`},
+		{"", "TINYINT", ""},
+		{"999", "VARCHAR(3)", ""},
+	}
+	for _, tt := range tests {
+		err := checkIntegerRange(tt.value, ParseDataType(tt.destType))
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("checkIntegerRange(%q, %s): %s", tt.value, tt.destType, err)
+		case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+			t.Errorf("checkIntegerRange(%q, %s) = %v, want %q", tt.value, tt.destType, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckSchemaTypes(t *testing.T) {
+	schema := testSchema(t, "a,tinyint,a,TINYINT\nb,tinyint,b,TINYINT UNSIGNED\nc,int,c,TINYINT\n", Options{})
+	warnings := CheckSchemaTypes(schema)
+	if len(warnings) != 1 || warnings[0].Column != "a" {
+		t.Errorf("CheckSchemaTypes = %v, want one warning for a", warnings)
+	}
+}
+
+func TestTinyintOutOfRange(t *testing.T) {
+	tests := []struct {
+		destType string
+		warnings int
+	}{
+		{"TINYINT", 1},
+		{"TINYINT UNSIGNED", 0},
+	}
+	for _, tt := range tests {
+		sql, report, err := generate(t, "flag,tinyint,flag,"+tt.destType+"\n", "flag\n1\n200\n", testOptions(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Warnings) != tt.warnings {
+			t.Errorf("%s: warnings %v, want %d", tt.destType, report.Warnings, tt.warnings)
+		}
+		if tt.warnings > 0 && (report.Warnings[0].Row != 2 || !strings.Contains(report.Warnings[0].Message, "200 is out of range")) {
+			t.Errorf("%s: warning %v, want 200 out of range on row 2", tt.destType, report.Warnings[0])
+		}
+		// 範囲外でも値は書き出し、MySQL 側で拒否させる
+		if !strings.Contains(sql, "('200')") {
+			t.Errorf("%s: value was not written:\n%s", tt.destType, sql)
+		}
+	}
+}
+
+func TestBareUnsignedBigint(t *testing.T) {
+	tests := []struct {
+		flags    []string
+		destType string
+		value    string
+		want     string
+		warnings int
+	}{
+		{nil, "BIGINT UNSIGNED", "18446744073709551615", "('18446744073709551615')", 0},
+		{[]string{"-bare-unsigned-bigint"}, "BIGINT UNSIGNED", "18446744073709551615", "(18446744073709551615)", 0},
+		{[]string{"-bare-unsigned-bigint"}, "BIGINT UNSIGNED", "9223372036854775808", "(9223372036854775808)", 0},
+		{[]string{"-bare-unsigned-bigint"}, "BIGINT UNSIGNED", "42", "(42)", 0},
+		{[]string{"-bare-unsigned-bigint"}, "BIGINT UNSIGNED", "18446744073709551616", "('18446744073709551616')", 1},
+		// 符号付きの BIGINT は対象外で、2^63 以上は範囲外
+		{[]string{"-bare-unsigned-bigint"}, "BIGINT", "9223372036854775808", "('9223372036854775808')", 1},
+	}
+	for _, tt := range tests {
+		schema := "id,decimal(20,0),id," + tt.destType + "\n"
+		sql, report, err := generate(t, schema, "id\n"+tt.value+"\n", testOptions(t, tt.flags...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(sql, tt.want) || len(report.Warnings) != tt.warnings {
+			t.Errorf("%q %s %s: warnings %v\n%s\nwant %s", tt.flags, tt.destType, tt.value, report.Warnings, sql, tt.want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const loadDataFileExtension = ".tsv"
+
+// LOAD DATA の既定の ESCAPED BY '\\' に合わせたデータファイル用のエスケープ
+var loadDataEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"\t", "\\t",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\x00", "\\0",
+)
+
+func validateLoadDataOptions(options *Options) error {
+	switch options.LineTerminator {
+	case `\n`, "lf":
+		options.LineTerminator = "\n"
+	case `\r\n`, "crlf":
+		options.LineTerminator = "\r\n"
+	case "\n", "\r\n":
+	default:
+		return fmt.Errorf(`invalid -line-terminator %q: must be \n or \r\n`, options.LineTerminator)
+	}
+
+	if options.LoadData && options.SplitRows > 0 {
+		return fmt.Errorf("-split-rows cannot be used with -load-data")
+	}
+	if options.LoadData && options.InsertModifier != "" {
+		return fmt.Errorf("-insert-modifier cannot be used with -load-data")
+	}
+	return nil
+}
+
+// WriteLoadData は変換した行をタブ区切りで dataWriter に書き出し、そのファイルを読み込む
+// LOAD DATA 文を sqlWriter に書き出します。行の区切りは文とデータファイルの両方で
+// options.LineTerminator を使うので、食い違うことはありません。
+func WriteLoadData(sqlWriter, dataWriter io.Writer, dataFileName, tableName string, schema []Schema, headerIndexMap map[string]int, inputReader *csv.Reader, options Options) (*Report, error) {
+	report := &Report{}
+	converter, err := newRowConverter(schema, headerIndexMap, options, report)
+	if err != nil {
+		return report, err
+	}
+
+	output := &countingWriter{writer: dataWriter}
+	err = converter.each(inputReader, func(rowNumber int, values []Value) error {
+		fields := make([]string, 0, len(values))
+		for j, value := range values {
+			if value == nullValue {
+				fields = append(fields, `\N`) // LOAD DATA の NULL の表記
+				continue
+			}
+			if value.Kind == KeywordValue {
+				return fmt.Errorf("row %d, column %s: %s cannot be written to a LOAD DATA file", rowNumber, schema[j].ColumnTo, value.Text)
+			}
+			fields = append(fields, loadDataEscaper.Replace(value.Text))
+		}
+
+		if _, err := io.WriteString(output, strings.Join(fields, "\t")+options.LineTerminator); err != nil {
+			return err
+		}
+		report.Rows++
+
+		if options.Progress != nil {
+			options.Progress.Update(report.Rows, output.n)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	if report.Rows == 0 {
+		if options.Progress != nil {
+			options.Progress.Finish(0, 0)
+		}
+		if options.EmptyFileOK {
+			return report, nil
+		}
+		return report, ErrNoDataRows
+	}
+
+	statement := preamble(options) + loadDataStatement(dataFileName, tableName, schema, options)
+	if _, err := io.WriteString(sqlWriter, normalizeNewlines(statement, lineEnding(options.LineEndings))); err != nil {
+		return report, err
+	}
+	if options.Progress != nil {
+		options.Progress.Finish(report.Rows, output.n)
+	}
+
+	return report, nil
+}
+
+func loadDataStatement(dataFileName, tableName string, schema []Schema, options Options) string {
+	columns := make([]string, 0, len(schema))
+	for _, column := range schema {
+		columns = append(columns, options.QuoteIdentifier(column.ColumnTo))
+	}
+
+	var sql strings.Builder
+	sql.WriteString(fmt.Sprintf("LOAD DATA LOCAL INFILE '%s'\n", escapeString(dataFileName)))
+	sql.WriteString(fmt.Sprintf("INTO TABLE %s\n", options.QuoteIdentifier(options.TableIdentifier(tableName))))
+	sql.WriteString("CHARACTER SET utf8mb4\n")
+	sql.WriteString("FIELDS TERMINATED BY '\\t' ESCAPED BY '\\\\'\n")
+	sql.WriteString(fmt.Sprintf("LINES TERMINATED BY '%s'\n", escapeString(options.LineTerminator)))
+	sql.WriteString(fmt.Sprintf("(%s);", strings.Join(columns, ", ")))
+	sql.WriteString(maintenanceStatements(tableName, options))
+	if options.TrailingNewline {
+		sql.WriteString("\n")
+	}
+	return sql.String()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestLoadDataLineTerminator(t *testing.T) {
+	tests := []struct {
+		flag       string
+		terminator string
+		clause     string
+	}{
+		{`\n`, "\n", `LINES TERMINATED BY '\n'`},
+		{"lf", "\n", `LINES TERMINATED BY '\n'`},
+		{`\r\n`, "\r\n", `LINES TERMINATED BY '\r\n'`},
+		{"crlf", "\r\n", `LINES TERMINATED BY '\r\n'`},
+	}
+	for _, tt := range tests {
+		options := testOptions(t, "-load-data", "-line-terminator", tt.flag)
+		schema := testSchema(t, "id,int,id,INT\nnote,nvarchar,note,TEXT\n", options)
+		reader := csv.NewReader(strings.NewReader("id,note\n1,\"a\tb\"\n2,\"x\r\ny\"\n3,\n"))
+		headers, err := ParseHeaders(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var sql, data bytes.Buffer
+		if _, err := WriteLoadData(&sql, &data, "t.tsv", "t", schema, MapHeadersToSchema(headers, schema), reader, options); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(sql.String(), tt.clause+"\n") {
+			t.Errorf("-line-terminator %s: statement\n%s\nwant %s", tt.flag, sql.String(), tt.clause)
+		}
+		// 値の中の改行はエスケープするので、行の区切りと取り違えない
+		want := strings.Join([]string{`1	a\tb`, `2	x\ny`, "3	"}, tt.terminator) + tt.terminator
+		if got := data.String(); got != want {
+			t.Errorf("-line-terminator %s: data file %q, want %q", tt.flag, got, want)
+		}
+	}
+}
+
+func TestLoadDataLineTerminatorInvalid(t *testing.T) {
+	for _, terminator := range []string{`\r`, ";", ""} {
+		if _, err := ParseArgs([]string{"convert", "-load-data", "-line-terminator", terminator, "t", "input.csv", "schema.csv"}); err == nil {
+			t.Errorf("ParseArgs accepted -line-terminator %q", terminator)
+		}
+	}
+}
@@ -3,119 +3,924 @@ package main
 import (
 	"bytes"
 	"encoding/csv"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
 )
 
 type Args struct {
 	TableName      string
 	InputFileName  string
 	SchemaFileName string
+	Options
 }
 
+type Options struct {
+	TablePrefix           string
+	TableSuffix           string
+	IdentifierQuote       string
+	InsertModifier        string
+	ValuesSyntax          string
+	ValuesOnly            bool
+	NullEmptyFor          string
+	QuoteAll              bool
+	QuoteNone             bool
+	FlattenWhitespace     bool
+	DateFormats           stringList
+	DateOutputFormat      string
+	BareUnsignedBigint    bool
+	SourceTZ              string
+	TargetTZ              string
+	FractionalSeconds     string
+	DatetimeEpoch         string
+	UUIDToBin             bool
+	UUIDSwap              bool
+	FourByteCheck         string
+	StrictDatatypeMatch   string
+	AllowWidening         bool
+	SetNames              string
+	Database              string
+	Analyze               bool
+	Optimize              bool
+	Collation             string
+	SQLMode               string
+	EmptyFileOK           bool
+	DedupeKey             string
+	DedupeIgnoreCase      bool
+	DedupeIgnoreAccents   bool
+	ReplaceGlobal         stringList
+	MaxPacket             int
+	BatchMarkers          bool
+	SplitRows             int
+	ChunkByKeyRange       int
+	ChunkKey              string
+	CheckpointEvery       int
+	Resume                bool
+	SkipFooter            int
+	SkipLines             int
+	TrimTrailingDelimiter bool
+	HeaderNormalize       bool
+	MaxRowLength          int
+	MaxValueBytes         int
+	MaxValueAction        string
+	FooterPattern         string
+	TableColumnsFile      string
+	PassthroughUnmapped   bool
+	ChecksumColumn        string
+	ChecksumAlgorithm     string
+	GeneratedColumns      string
+	LoadData              bool
+	LineTerminator        string
+	Prepared              bool
+	PlaceholderDialect    string
+	ReportUnconverted     bool
+	FailOnWarning         bool
+	Report                string
+	Stats                 bool
+	ValidateForeignKeys   stringList
+	DryRunSQL             bool
+	PreviewDiff           string
+	DiffKey               string
+	SchemaValidate        bool
+	InputDir              string
+	SchemaEncoding        string
+	TransformLocale       string
+	TransformAudit        int
+	WriteRejected         bool
+	ParallelWithinFile    int
+	Upsert                bool
+	UpsertExclude         string
+	UpsertSet             stringList
+	UpsertBinaryKey       string
+	UpsertKeyCollation    string
+	TrailingNewline       bool
+	LineEndings           string
+	ProgressJSON          bool
+	ProgressInterval      time.Duration
+
+	// -source-tz/-target-tz を読み込んだもので、validateTimezoneOptions が設定します
+	sourceLocation *time.Location
+	targetLocation *time.Location
+	// -null-empty-for を読み込んだ転送先の型名の集合で、ParseArgs が設定します
+	nullEmptyFor map[string]bool
+	// -replace-global を読み込んだもので、ParseArgs が設定します
+	globalReplacements map[string]globalReplacement
+	// -sql-mode に NO_BACKSLASH_ESCAPES を含むかどうかで、ParseArgs が設定します
+	noBackslashEscapes bool
+	// -transform-locale を読み込んだもので、ParseArgs が設定します
+	transformLocale language.Tag
+	// -resume で読み込んだチェックポイントで、ConvertFile が設定します
+	resume Checkpoint
+	// Progress は -progress-json 指定時に main で設定されます
+	Progress *ProgressReporter
+	// Rejected は -write-rejected 指定時に main で設定されます
+	Rejected *RejectedRows
+}
+
+// MySQL の識別子(テーブル名・カラム名)の最大長
+const maxIdentifierLength = 64
+
+const (
+	ValuesSyntaxStandard = "standard"
+	ValuesSyntaxRow      = "row" // MySQL 8 の VALUES ROW(...) 形式
+)
+
+var (
+	ErrEmptyInput = errors.New("input file is empty; use -empty-file-ok to allow it")
+	ErrNoDataRows = errors.New("input file has no data rows; use -empty-file-ok to allow it")
+)
+
 type Schema struct {
 	ColumnFrom   string
 	DataTypeFrom string
 	ColumnTo     string
 	DataTypeTo   string
+	Transforms   []Transform
+	Expression   ColumnExpression
+	Checksum     string // -checksum-column で追加したカラムのハッシュアルゴリズム
 }
 
 func main() {
 	args, err := ParseArgs(os.Args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
 		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if args.SchemaValidate {
+		schema, err := ReadSchema(args.SchemaFileName, args.Options)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		problems := ValidateSchema(schema)
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+		fmt.Printf("Schema file %s is valid.\n", args.SchemaFileName)
 		return
 	}
 
-	schema, err := ReadSchema(args.SchemaFileName)
+	if args.InputDir != "" {
+		err = ConvertDir(*args)
+	} else {
+		err = ConvertFile(*args)
+	}
 	if err != nil {
 		fmt.Println(err)
-		return
+		os.Exit(1)
 	}
+}
 
-	reader, err := ReadInputFile(args.InputFileName)
+// ConvertFile は1つの入力ファイルを変換し、結果のファイルを書き出します。
+func ConvertFile(args Args) error {
+	schema, err := ReadSchema(args.SchemaFileName, args.Options)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
+	}
+
+	generated := parseColumnList(args.GeneratedColumns)
+	schema = ExcludeGeneratedColumns(schema, generated)
+
+	schemaWarnings := CheckSchemaTypes(schema)
+	if args.StrictDatatypeMatch != StrictDatatypeMatchOff {
+		mismatches := CheckDatatypeMatch(schema, args.AllowWidening)
+		if args.StrictDatatypeMatch == StrictDatatypeMatchError && len(mismatches) > 0 {
+			for _, mismatch := range mismatches {
+				fmt.Fprintf(os.Stderr, "%s\n", mismatch)
+			}
+			return fmt.Errorf("%d schema mappings are not lossless; -strict-datatype-match error stops the conversion", len(mismatches))
+		}
+		schemaWarnings = append(schemaWarnings, mismatches...)
+	}
+	if args.TableColumnsFile != "" {
+		tableColumns, err := ReadTableColumns(args.TableColumnsFile)
+		if err != nil {
+			return err
+		}
+		tableColumns = excludeGeneratedTableColumns(tableColumns, generated)
+		var orderWarnings []Warning
+		schema, orderWarnings = ReorderSchema(schema, tableColumns)
+		schemaWarnings = append(schemaWarnings, orderWarnings...)
+	}
+
+	if args.ChecksumColumn != "" {
+		column, err := checksumColumn(schema, args.Options)
+		if err != nil {
+			return err
+		}
+		schema = append(schema, column)
 	}
 
-	headers, err := ParseHeaders(reader)
+	input, err := ReadInputFile(args.InputFileName)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
+	defer input.Close()
 
-	headerIndexMap := MapHeadersToSchema(headers, schema)
+	var reader io.Reader = input
+	if reader, err = skipLines(reader, args.SkipLines); err != nil {
+		return err
+	}
 
-	outputSQL := GenerateSQL(args.TableName, schema, headerIndexMap, reader)
+	if args.ProgressJSON {
+		var totalBytes int64
+		if info, err := os.Stat(args.InputFileName); err == nil {
+			totalBytes = info.Size()
+		}
+		args.Progress = NewProgressReporter(os.Stderr, args.ProgressInterval, totalBytes)
+		reader = args.Progress.Reader(reader)
+	}
 
-	if err := WriteSQLToFile(outputSQL, args.TableName); err != nil {
-		fmt.Println(err)
-		return
+	reader, err = newFooterReader(reader, args.SkipFooter, args.FooterPattern)
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("SQL file %s.SQL has been generated successfully.\n", args.TableName)
+	rejectedOutput := NewFileOutput(args.TableName, rejectedFileExtension, false)
+	// -preview-diff や -stats のように SQL を書かずに戻る場合も、読み捨てた行を書き出して閉じる
+	defer rejectedOutput.Close()
+	if args.WriteRejected {
+		args.Rejected = NewRejectedRows(rejectedOutput)
+		reader = args.Rejected.Reader(reader)
+	}
+
+	csvReader := csv.NewReader(reader)
+
+	extension := ".SQL"
+	if args.Prepared {
+		extension = preparedFileExtension
+	}
+	output := NewFileOutput(args.TableName, extension, args.SplitRows > 0 || args.ChunkByKeyRange > 0 || args.CheckpointEvery > 0)
+	if args.CheckpointEvery > 0 {
+		output.atomic = true
+	}
+	if args.Resume {
+		if args.resume, err = readCheckpoint(args.TableName); err != nil {
+			return err
+		}
+		if args.resume.Every != args.CheckpointEvery {
+			return fmt.Errorf("checkpoint %s was written with -checkpoint-every %d; resume with the same value", checkpointFileName(args.TableName), args.resume.Every)
+		}
+		output.part = args.resume.Part + 1
+		fmt.Printf("Resuming after row %d (%s).\n", args.resume.Row, output.fileName())
+	} else if args.CheckpointEvery > 0 {
+		// 前回の記録が残っていると、今回の途中で止まったときに誤った位置から再開してしまう
+		if err := removeCheckpoint(args.TableName); err != nil {
+			return err
+		}
+	}
+	report := &Report{}
+	var dataFileNames []string
+	headers, err := ParseHeaders(csvReader)
+	if err == nil && args.TrimTrailingDelimiter {
+		headers, err = trimTrailingDelimiter(0, headers)
+	}
+	if err == nil && args.HeaderNormalize {
+		headers, err = normalizeHeaders(headers)
+	}
+	switch {
+	case errors.Is(err, ErrEmptyInput) && args.EmptyFileOK:
+		// SQL を書かないモードでは、空の入力でも空の SQL ファイルを作らない
+		if args.DryRunSQL || args.Stats || args.PreviewDiff != "" || len(args.ValidateForeignKeys) > 0 {
+			warned := printWarnings(schemaWarnings)
+			fmt.Println("Input file is empty. No file was written.")
+			return args.warningsError(warned)
+		}
+	case err != nil:
+		return err
+	default:
+		if args.PassthroughUnmapped {
+			var passthroughWarnings []Warning
+			schema, passthroughWarnings = PassthroughUnmapped(schema, headers, generated)
+			schemaWarnings = append(schemaWarnings, passthroughWarnings...)
+		}
+		headerIndexMap := MapHeadersToSchema(headers, schema)
+		if err := CheckExpressionColumns(schema, headerIndexMap); err != nil {
+			return err
+		}
+		if args.Rejected != nil {
+			args.Rejected.setHeader(csvReader.InputOffset())
+		}
+
+		if args.PreviewDiff != "" {
+			summary, report, err := PreviewDiff(schema, headerIndexMap, csvReader, args.Options)
+			if err != nil {
+				return err
+			}
+			warned := printWarnings(append(schemaWarnings, report.Warnings...))
+			fmt.Printf("%d rows to insert, %d rows to update, %d rows unchanged.\n", summary.Inserts, summary.Updates, summary.Unchanged)
+			return args.warningsError(warned)
+		}
+
+		if len(args.ValidateForeignKeys) > 0 {
+			summary, report, err := ValidateForeignKeys(schema, headerIndexMap, csvReader, args.Options)
+			if err != nil {
+				return err
+			}
+			warned := printWarnings(append(schemaWarnings, report.Warnings...))
+			for _, orphan := range summary.Orphans {
+				fmt.Println(orphan)
+			}
+			if summary.OrphanValues > len(summary.Orphans) {
+				fmt.Printf("... %d more missing keys not listed\n", summary.OrphanValues-len(summary.Orphans))
+			}
+			if summary.OrphanRows > 0 {
+				return fmt.Errorf("%d of %d rows reference keys missing from the parent keys files", summary.OrphanRows, report.Rows)
+			}
+			fmt.Printf("All %d rows reference existing parent keys.\n", report.Rows)
+			return args.warningsError(warned)
+		}
+
+		if args.Stats {
+			stats, report, err := ComputeStats(schema, headerIndexMap, csvReader, args.Options)
+			if err != nil {
+				return err
+			}
+			warned := printWarnings(append(schemaWarnings, report.Warnings...))
+			fmt.Printf("%d rows\n", report.Rows)
+			if err := WriteStats(os.Stdout, stats); err != nil {
+				return err
+			}
+			return args.warningsError(warned)
+		}
+
+		if args.DryRunSQL {
+			discard := &countingWriter{writer: io.Discard}
+			start := time.Now()
+			report, err := WriteSQL(discard, args.TableName, schema, headerIndexMap, csvReader, args.Options)
+			elapsed := time.Since(start)
+			if err != nil {
+				return err
+			}
+			warned := printWarnings(append(schemaWarnings, report.Warnings...))
+			seconds := elapsed.Seconds()
+			fmt.Printf("Dry run generated %d rows (%d bytes) in %s: %.0f rows/sec, %.2f MB/sec. No file was written.\n",
+				report.Rows, discard.n, elapsed.Round(time.Millisecond), float64(report.Rows)/seconds, float64(discard.n)/seconds/1e6)
+			return args.warningsError(warned)
+		}
+
+		if args.LoadData {
+			dataOutput := NewFileOutput(args.TableName, loadDataFileExtension, false)
+			report, err = WriteLoadData(output, dataOutput, args.TableName+loadDataFileExtension, args.TableName, schema, headerIndexMap, csvReader, args.Options)
+			if closeErr := dataOutput.Close(); err == nil {
+				err = closeErr
+			}
+			dataFileNames = dataOutput.FileNames
+		} else if args.Prepared {
+			report, err = WritePrepared(output, args.TableName, schema, headerIndexMap, csvReader, args.Options)
+		} else {
+			report, err = WriteSQL(output, args.TableName, schema, headerIndexMap, csvReader, args.Options)
+		}
+		if err != nil && output.atomic {
+			// 書きかけのファイルは完成させず、-resume で書き直す
+			output.abandon()
+		} else if closeErr := output.Close(); err == nil {
+			err = closeErr
+		}
+		if closeErr := rejectedOutput.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	report.Warnings = append(schemaWarnings, report.Warnings...)
+	warned := printWarnings(report.Warnings)
+
+	// 変換のない型の組み合わせには date -> DATE のようにそのまま通してよいものも含まれるので、
+	// -fail-on-warning で警告に数えるのは -report-unconverted-types で一覧を求めたときだけにする
+	if args.ReportUnconverted && len(report.UnconvertedTypes) > 0 {
+		warned += len(report.UnconvertedTypes)
+		fmt.Fprintln(os.Stderr, "type mappings passed through without conversion:")
+		for _, mapping := range report.SortedUnconvertedTypes() {
+			fmt.Fprintf(os.Stderr, "  %s (%d values)\n", mapping, report.UnconvertedTypes[mapping])
+		}
+	}
+
+	if len(report.DateFormats) > 0 {
+		fmt.Fprintln(os.Stderr, "date formats used:")
+		for _, usage := range report.SortedDateFormats() {
+			fmt.Fprintf(os.Stderr, "  %s: %s (%d values)\n", usage.Column, usage.Layout, report.DateFormats[usage])
+		}
+	}
+
+	if args.Report == ReportMarkdown {
+		var md strings.Builder
+		if err := WriteMarkdownReport(&md, args.TableName, schema, report); err != nil {
+			return err
+		}
+		reportFileName := args.TableName + reportFileExtension
+		if err := os.WriteFile(reportFileName, []byte(md.String()), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %s", reportFileName, err)
+		}
+		fmt.Printf("Conversion report %s has been generated.\n", reportFileName)
+	}
+
+	if len(report.TransformSamples) > 0 {
+		fmt.Fprintln(os.Stderr, "transform audit:")
+		for _, sample := range report.TransformSamples {
+			fmt.Fprintf(os.Stderr, "  %s\n", sample)
+		}
+	}
+
+	if report.RejectedRows > 0 && len(rejectedOutput.FileNames) > 0 {
+		fmt.Printf("%d rejected rows have been written to %s.\n", report.RejectedRows, rejectedOutput.FileNames[0])
+	}
+
+	if report.DuplicateRows > 0 {
+		fmt.Printf("%d duplicate rows have been dropped.\n", report.DuplicateRows)
+	}
+
+	if report.Rows == 0 {
+		// 書き込みがなければ出力ファイルは作られないので、空のファイルを作る
+		_, err := output.Write(nil)
+		if closeErr := output.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Input file has no data rows; empty SQL file %s has been generated.\n", output.FileNames[0])
+		return args.warningsError(warned)
+	}
+
+	if args.CheckpointEvery > 0 {
+		if err := removeCheckpoint(args.TableName); err != nil {
+			return err
+		}
+	}
+
+	for _, dataFileName := range dataFileNames {
+		fmt.Printf("Data file %s has been generated for LOAD DATA.\n", dataFileName)
+	}
+
+	if len(output.FileNames) > 1 {
+		fmt.Printf("SQL files %s have been generated successfully.\n", strings.Join(output.FileNames, ", "))
+		return args.warningsError(warned)
+	}
+	fmt.Printf("SQL file %s has been generated successfully.\n", output.FileNames[0])
+	return args.warningsError(warned)
+}
+
+// printWarnings は警告を標準エラーに書き出し、その件数を返します。
+func printWarnings(warnings []Warning) int {
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	return len(warnings)
+}
+
+// warningsError は -fail-on-warning 指定時に、警告があればエラーを返します。
+// 出力ファイルを書き終えた後に呼ぶので、エラーになっても生成結果は残ります。
+func (o Options) warningsError(warned int) error {
+	if !o.FailOnWarning || warned == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d warnings were reported; failing because of -fail-on-warning", warned)
 }
 
 func ParseArgs(args []string) (*Args, error) {
-	if len(args) < 4 {
-		return nil, fmt.Errorf("usage: convert [table name] [input file name] [schema info CSV file name]")
+	var options Options
+
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	flags.StringVar(&options.TablePrefix, "table-prefix", "", "prefix added to the table name in generated SQL")
+	flags.StringVar(&options.TableSuffix, "table-suffix", "", "suffix added to the table name in generated SQL")
+	flags.StringVar(&options.IdentifierQuote, "identifier-quote", IdentifierQuoteBacktick, "quote identifiers with backtick or double (for sql_mode ANSI_QUOTES)")
+	flags.StringVar(&options.InsertModifier, "insert-modifier", "", "modifiers after INSERT: LOW_PRIORITY, HIGH_PRIORITY or DELAYED, and IGNORE (e.g. HIGH_PRIORITY,IGNORE)")
+	flags.BoolVar(&options.ValuesOnly, "values-only", false, "write only the comma-separated VALUES tuples, without INSERT INTO or the closing semicolon, for splicing into an existing statement")
+	flags.StringVar(&options.ValuesSyntax, "values-syntax", ValuesSyntaxStandard, "syntax of VALUES tuples: standard or row")
+	flags.StringVar(&options.NullEmptyFor, "null-empty-for", "", "comma-separated destination types (or numeric, date) whose empty values are written as NULL")
+	flags.BoolVar(&options.QuoteAll, "quote-all", false, "quote every value, including numbers")
+	flags.BoolVar(&options.QuoteNone, "quote-none", false, "write every value unquoted; only for trusted numeric data")
+	flags.BoolVar(&options.FlattenWhitespace, "flatten-whitespace", false, "collapse runs of whitespace, including newlines and tabs, into single spaces in all text columns")
+	flags.BoolVar(&options.BareUnsignedBigint, "bare-unsigned-bigint", false, "write values for BIGINT UNSIGNED, including those above 2^63-1, as unquoted numbers")
+	flags.BoolVar(&options.UUIDToBin, "uuid-to-bin", false, "write uniqueidentifier values for BINARY(16) as UUID_TO_BIN(...) calls instead of hex literals")
+	flags.BoolVar(&options.UUIDSwap, "uuid-swap", false, "swap the time fields of binary UUIDs like UUID_TO_BIN(uuid, 1) for index locality")
+	flags.StringVar(&options.StrictDatatypeMatch, "strict-datatype-match", StrictDatatypeMatchOff, "report schema mappings that are not lossless (widening or lossy, e.g. datetime to VARCHAR): off, warn or error")
+	flags.BoolVar(&options.AllowWidening, "allow-widening", false, "accept widening mappings under -strict-datatype-match and only report lossy ones")
+	flags.StringVar(&options.FourByteCheck, "force-utf8mb4-4byte-check", FourByteCheckOff, "report 4-byte UTF-8 characters that utf8 (3-byte) columns cannot store: off, warn or error")
+	flags.StringVar(&options.Database, "database", "", "emit USE with this database before the inserts")
+	flags.StringVar(&options.SQLMode, "sql-mode", "", "emit SET SESSION sql_mode with these comma-separated modes before the inserts; NO_BACKSLASH_ESCAPES switches string escaping to doubled quotes")
+	flags.StringVar(&options.SetNames, "set-names", "", "emit SET NAMES with this character set (e.g. utf8mb4) before the inserts")
+	flags.StringVar(&options.Collation, "collation", "", "collation for -set-names")
+	flags.StringVar(&options.LineEndings, "normalize-line-endings", "", "write the SQL with lf or crlf line endings regardless of platform")
+	flags.BoolVar(&options.TrailingNewline, "trailing-newline", true, "end the generated SQL with a newline")
+	flags.BoolVar(&options.EmptyFileOK, "empty-file-ok", false, "allow inputs without data rows and generate an empty SQL file")
+	flags.Var(&options.ReplaceGlobal, "replace-global", `replace input values equal to "from" in every column before conversion, as from=to; to \N writes NULL (repeatable)`)
+	flags.StringVar(&options.DedupeKey, "dedupe-key", "", "comma-separated destination columns; rows repeating an earlier key are dropped")
+	flags.BoolVar(&options.DedupeIgnoreCase, "dedupe-ignore-case", false, "treat -dedupe-key values differing only in letter case as duplicates, like a _CI collation")
+	flags.BoolVar(&options.DedupeIgnoreAccents, "dedupe-ignore-accents", false, "treat -dedupe-key values differing only in accents as duplicates, like an _AI collation")
+	flags.IntVar(&options.MaxPacket, "max-packet", 0, "maximum bytes per INSERT statement (e.g. the server's max_allowed_packet); 0 means no limit")
+	flags.BoolVar(&options.BatchMarkers, "batch-markers", false, "surround each INSERT statement with -- batch N start/end comments so external runners can checkpoint and resume")
+	flags.IntVar(&options.SplitRows, "split-rows", 0, "maximum rows per output file; 0 writes a single file")
+	flags.IntVar(&options.CheckpointEvery, "checkpoint-every", 0, "write output files of this many rows and record each completed file in TABLE.checkpoint, so an interrupted run can be resumed")
+	flags.BoolVar(&options.Resume, "resume", false, "continue an interrupted -checkpoint-every run after the rows recorded in TABLE.checkpoint")
+	flags.IntVar(&options.ChunkByKeyRange, "chunk-by-key-range", 0, "split output files by ranges of this many -chunk-key values (1-N, N+1-2N, ...); input must be sorted by the key")
+	flags.StringVar(&options.ChunkKey, "chunk-key", "", "integer destination column used by -chunk-by-key-range")
+	flags.BoolVar(&options.HeaderNormalize, "header-normalize", false, "strip SQL Server decorations from input headers, such as [Column] brackets and dbo. schema prefixes")
+	flags.BoolVar(&options.TrimTrailingDelimiter, "trim-trailing-delimiter", false, "drop the empty last field of inputs whose lines all end with a delimiter")
+	flags.IntVar(&options.MaxValueBytes, "max-value-bytes", 0, "maximum bytes of a single input value; 0 means no limit")
+	flags.StringVar(&options.MaxValueAction, "max-value-action", MaxValueError, "what to do with values over -max-value-bytes: error or truncate")
+	flags.IntVar(&options.SkipLines, "skip-lines", 0, "number of lines to ignore before the header, such as notes printed before the data")
+	flags.IntVar(&options.SkipFooter, "skip-footer", 0, "number of lines to ignore at the end of the input")
+	flags.IntVar(&options.MaxRowLength, "max-row-length", 0, fmt.Sprintf("abort when a row has more fields than this; 0 means %d times the header width", defaultRowLengthFactor))
+	flags.StringVar(&options.FooterPattern, "footer-pattern", "", `ignore trailing lines matching this regexp; "rows-affected" matches SQL Server's "(N rows affected)"`)
+	flags.StringVar(&options.ChecksumColumn, "checksum-column", "", "append a column with this name holding a hash of each row's source values")
+	flags.StringVar(&options.ChecksumAlgorithm, "checksum-algorithm", ChecksumSHA1, "hash for -checksum-column: sha1, md5 or sha256")
+	flags.BoolVar(&options.PassthroughUnmapped, "passthrough-unmapped", false, "also insert input columns missing from the schema, using the input column name unchanged")
+	flags.StringVar(&options.GeneratedColumns, "generated-columns", "", "comma-separated generated (virtual or stored) columns of the target table, left out of the generated INSERTs")
+	flags.StringVar(&options.TableColumnsFile, "table-columns", "", "information_schema.columns CSV dump of the target table; output columns follow its order")
+	flags.BoolVar(&options.LoadData, "load-data", false, "write a tab-separated data file and a LOAD DATA statement instead of INSERTs")
+	flags.StringVar(&options.LineTerminator, "line-terminator", `\n`, `line terminator of the -load-data file: \n or \r\n`)
+	flags.BoolVar(&options.Analyze, "analyze", false, "append ANALYZE TABLE after the inserts to refresh index statistics")
+	flags.BoolVar(&options.Optimize, "optimize", false, "append OPTIMIZE TABLE after the inserts")
+	flags.BoolVar(&options.Upsert, "upsert", false, "add ON DUPLICATE KEY UPDATE for all columns")
+	flags.StringVar(&options.UpsertExclude, "upsert-exclude", "", "comma-separated columns not updated by -upsert (e.g. created_at)")
+	flags.Var(&options.UpsertSet, "upsert-set", "column=expression assigned by -upsert instead of the inserted value (e.g. updated_at=NOW()); repeatable")
+	flags.StringVar(&options.UpsertBinaryKey, "upsert-binary-key", "", "comma-separated key columns that -upsert compares case- and accent-sensitively; rows are only updated when these match exactly")
+	flags.StringVar(&options.UpsertKeyCollation, "upsert-key-collation", "utf8mb4_bin", "binary collation used by -upsert-binary-key")
+	flags.BoolVar(&options.Prepared, "prepared", false, "write a parameterized INSERT and per-row arguments as JSON lines for driver-side prepared statements")
+	flags.StringVar(&options.PlaceholderDialect, "placeholder-dialect", PlaceholderQuestion, "placeholders for -prepared: question (?), dollar ($1) or named (:column)")
+	flags.StringVar(&options.DateOutputFormat, "date-output-format", "", "Go reference-time layout for writing converted date and datetime values (e.g. 2006-01-02T15:04:05); MySQL's format if omitted")
+	flags.Var(&options.DateFormats, "date-format", "Go time layout (e.g. 2006-01-02 or 01/02/2006) tried in order when reading text columns into DATE/DATETIME; repeatable")
+	flags.StringVar(&options.DatetimeEpoch, "datetime-epoch", "", "write datetime values going to integer columns as Unix time in seconds or milliseconds")
+	flags.StringVar(&options.FractionalSeconds, "fractional-seconds", "", "reduce datetime fractional seconds to the DATETIME(n) precision: round or truncate")
+	flags.StringVar(&options.SourceTZ, "source-tz", "", "time zone of datetime values in the input (e.g. Asia/Tokyo); requires -target-tz")
+	flags.StringVar(&options.TargetTZ, "target-tz", "", "time zone datetime values are converted to (e.g. UTC)")
+	flags.IntVar(&options.ParallelWithinFile, "parallel-within-file", 0, "number of goroutines converting rows; output keeps the input order. 0 or 1 converts sequentially")
+	flags.BoolVar(&options.WriteRejected, "write-rejected", false, "write rows that could not be read, with the header, to TABLE"+rejectedFileExtension)
+	flags.StringVar(&options.PreviewDiff, "preview-diff", "", "CSV dump of the target table; count rows that would be inserted, updated or unchanged instead of generating SQL")
+	flags.StringVar(&options.DiffKey, "diff-key", "", "comma-separated key columns for -preview-diff; defaults to the first schema column")
+	flags.BoolVar(&options.DryRunSQL, "dry-run-sql", false, "generate the SQL without writing it and report elapsed time and throughput")
+	flags.Var(&options.ValidateForeignKeys, "validate-foreign-keys", "check instead of generating SQL that a destination column only references keys in a parent keys CSV (first column, with header), as column=file (repeatable)")
+	flags.BoolVar(&options.Stats, "stats", false, "print per-column empty counts, min/max and distinct counts instead of generating SQL")
+	flags.StringVar(&options.SchemaEncoding, "schema-encoding", EncodingAuto, "character encoding of the schema file: auto, utf-8 or shift_jis; auto reads non-UTF-8 files as Shift-JIS")
+	flags.IntVar(&options.TransformAudit, "transform-audit", 0, "print the first N before/after value pairs of each column that has transforms, to check them before a full run")
+	flags.StringVar(&options.TransformLocale, "transform-locale", "", "language for the upper and lower transforms (e.g. tr for Turkish dotted and dotless i); Unicode default casing if omitted")
+	flags.StringVar(&options.InputDir, "input-dir", "", "convert every .csv in this directory, using the file name as the table name and NAME"+schemaFileSuffix+" or the given schema")
+	flags.BoolVar(&options.SchemaValidate, "schema-validate", false, "only check the schema file and exit; takes the schema file as the sole argument")
+	flags.StringVar(&options.Report, "report", "", "also write a summary of each column's conversion to TABLE.report.md: markdown")
+	flags.BoolVar(&options.FailOnWarning, "fail-on-warning", false, "exit with status 1 if any warning was reported; output files are still written")
+	flags.BoolVar(&options.ReportUnconverted, "report-unconverted-types", false, "list type mappings that were passed through without conversion; -fail-on-warning then counts them as warnings")
+	flags.BoolVar(&options.ProgressJSON, "progress-json", false, "write progress as JSON lines to stderr")
+	flags.DurationVar(&options.ProgressInterval, "progress-interval", time.Second, "interval between -progress-json updates")
+
+	if len(args) > 0 {
+		if err := flags.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateEncoding("-schema-encoding", options.SchemaEncoding); err != nil {
+		return nil, err
+	}
+
+	if options.TransformLocale != "" {
+		var err error
+		if options.transformLocale, err = language.Parse(options.TransformLocale); err != nil {
+			return nil, fmt.Errorf("invalid -transform-locale %q: %s", options.TransformLocale, err)
+		}
+	}
+
+	positional := flags.Args()
+	var result Args
+	switch {
+	case options.SchemaValidate:
+		if len(positional) != 1 {
+			return nil, fmt.Errorf("usage: convert -schema-validate [schema info CSV file name]")
+		}
+		result.SchemaFileName = positional[0]
+	case options.InputDir != "":
+		// テーブル名は入力ファイルごとに決まるので、長さは ConvertDir で確認する
+		if len(positional) > 1 {
+			return nil, fmt.Errorf("usage: convert [options] -input-dir [directory] [shared schema info CSV file name]")
+		}
+		if len(positional) == 1 {
+			result.SchemaFileName = positional[0]
+		}
+	default:
+		if len(positional) < 3 {
+			return nil, fmt.Errorf("usage: convert [options] [table name] [input file name] [schema info CSV file name]")
+		}
+		result.TableName, result.InputFileName, result.SchemaFileName = positional[0], positional[1], positional[2]
+
+		tableName := options.TableIdentifier(result.TableName)
+		if n := utf8.RuneCountInString(tableName); n > maxIdentifierLength {
+			return nil, fmt.Errorf("table name %q is %d characters long, exceeding the MySQL limit of %d", tableName, n, maxIdentifierLength)
+		}
+	}
+
+	switch options.ValuesSyntax {
+	case ValuesSyntaxStandard, ValuesSyntaxRow:
+	default:
+		return nil, fmt.Errorf("invalid -values-syntax %q: must be %s or %s", options.ValuesSyntax, ValuesSyntaxStandard, ValuesSyntaxRow)
+	}
+
+	switch options.IdentifierQuote {
+	case IdentifierQuoteBacktick, IdentifierQuoteDouble:
+	default:
+		return nil, fmt.Errorf("invalid -identifier-quote %q: must be %s or %s", options.IdentifierQuote, IdentifierQuoteBacktick, IdentifierQuoteDouble)
+	}
+
+	var err error
+	if options.nullEmptyFor, err = parseNullEmptyFor(options.NullEmptyFor); err != nil {
+		return nil, err
+	}
+	if options.globalReplacements, err = parseGlobalReplacements(options.ReplaceGlobal); err != nil {
+		return nil, err
+	}
+
+	if _, err := parseInsertModifiers(options.InsertModifier); err != nil {
+		return nil, err
+	}
+
+	if options.QuoteAll && options.QuoteNone {
+		return nil, fmt.Errorf("-quote-all and -quote-none cannot be used together")
+	}
+
+	if err := validateStrictDatatypeMatch(options.StrictDatatypeMatch); err != nil {
+		return nil, err
+	}
+	if err := validateFourByteCheck(options.FourByteCheck); err != nil {
+		return nil, err
+	}
+
+	if err := validateLineEndings(options.LineEndings); err != nil {
+		return nil, err
 	}
 
-	return &Args{
-		TableName:      args[1],
-		InputFileName:  args[2],
-		SchemaFileName: args[3],
-	}, nil
+	options.noBackslashEscapes = hasSQLMode(options.SQLMode, sqlModeNoBackslashEscapes)
+	if err := validatePreambleOptions(options); err != nil {
+		return nil, err
+	}
+
+	if options.SkipLines < 0 {
+		return nil, fmt.Errorf("invalid -skip-lines %d: must not be negative", options.SkipLines)
+	}
+	if options.SkipFooter < 0 {
+		return nil, fmt.Errorf("invalid -skip-footer %d: must not be negative", options.SkipFooter)
+	}
+
+	if options.MaxRowLength < 0 {
+		return nil, fmt.Errorf("invalid -max-row-length %d: must not be negative", options.MaxRowLength)
+	}
+
+	if err := validateMaxValueOptions(options); err != nil {
+		return nil, err
+	}
+
+	if options.MaxPacket < 0 {
+		return nil, fmt.Errorf("invalid -max-packet %d: must not be negative", options.MaxPacket)
+	}
+
+	if options.SplitRows < 0 {
+		return nil, fmt.Errorf("invalid -split-rows %d: must not be negative", options.SplitRows)
+	}
+
+	if err := validateDedupeOptions(options); err != nil {
+		return nil, err
+	}
+	if err := validateCheckpointOptions(options); err != nil {
+		return nil, err
+	}
+	if err := validateChunkOptions(options); err != nil {
+		return nil, err
+	}
+	if err := validateValuesOnlyOptions(options); err != nil {
+		return nil, err
+	}
+	if err := validateUpsertOptions(options); err != nil {
+		return nil, err
+	}
+
+	if err := validateMaintenanceOptions(options); err != nil {
+		return nil, err
+	}
+
+	if err := validatePreparedOptions(options); err != nil {
+		return nil, err
+	}
+
+	if err := validateLoadDataOptions(&options); err != nil {
+		return nil, err
+	}
+
+	if options.DryRunSQL && (options.LoadData || options.Prepared || options.WriteRejected) {
+		return nil, fmt.Errorf("-dry-run-sql cannot be used with -load-data, -prepared or -write-rejected")
+	}
+
+	if options.Report != "" && (options.DryRunSQL || options.Stats || options.PreviewDiff != "" || len(options.ValidateForeignKeys) > 0) {
+		return nil, fmt.Errorf("-report cannot be used with -dry-run-sql, -stats, -preview-diff or -validate-foreign-keys")
+	}
+	if len(options.ValidateForeignKeys) > 0 && (options.DryRunSQL || options.Stats || options.PreviewDiff != "") {
+		return nil, fmt.Errorf("-validate-foreign-keys cannot be used with -dry-run-sql, -stats or -preview-diff")
+	}
+	if options.Stats && options.PreviewDiff != "" {
+		return nil, fmt.Errorf("-stats and -preview-diff cannot be used together")
+	}
+
+	if options.DiffKey != "" && options.PreviewDiff == "" {
+		return nil, fmt.Errorf("-diff-key requires -preview-diff")
+	}
+
+	if err := validateChecksumOptions(options); err != nil {
+		return nil, err
+	}
+
+	if err := validateReportFormat(options.Report); err != nil {
+		return nil, err
+	}
+	if err := validateDatetimeEpoch(options.DatetimeEpoch); err != nil {
+		return nil, err
+	}
+	if err := validateDateOutputFormat(options.DateOutputFormat); err != nil {
+		return nil, err
+	}
+	if err := validateFractionalSeconds(options.FractionalSeconds); err != nil {
+		return nil, err
+	}
+
+	if err := validateTimezoneOptions(&options); err != nil {
+		return nil, err
+	}
+
+	if options.TransformAudit < 0 {
+		return nil, fmt.Errorf("invalid -transform-audit %d: must not be negative", options.TransformAudit)
+	}
+	if options.ParallelWithinFile < 0 {
+		return nil, fmt.Errorf("invalid -parallel-within-file %d: must not be negative", options.ParallelWithinFile)
+	}
+
+	if options.ProgressInterval <= 0 {
+		return nil, fmt.Errorf("invalid -progress-interval %s: must be positive", options.ProgressInterval)
+	}
+
+	result.Options = options
+	return &result, nil
 }
 
-func ReadSchema(schemaFileName string) ([]Schema, error) {
-	schemaFile, err := os.Open(schemaFileName)
+const (
+	IdentifierQuoteBacktick = "backtick"
+	IdentifierQuoteDouble   = "double"
+)
+
+// QuoteIdentifier は -identifier-quote の引用符で識別子を囲みます。識別子中の引用符は重ねます。
+// ANSI_QUOTES が有効なサーバーでは double を使います。
+func (o Options) QuoteIdentifier(name string) string {
+	quote := "`"
+	if o.IdentifierQuote == IdentifierQuoteDouble {
+		quote = `"`
+	}
+	return quote + strings.ReplaceAll(name, quote, quote+quote) + quote
+}
+
+// TableIdentifier は -table-prefix/-table-suffix を付けたテーブル名を返します。
+func (o Options) TableIdentifier(tableName string) string {
+	return o.TablePrefix + tableName + o.TableSuffix
+}
+
+// ReadSchema はスキーマの CSV ファイルを読み込みます。
+// ファイル名の代わりに env:NAME と書くと、環境変数 NAME の内容(CSV または JSON)を読みます。
+// ファイルの文字コードは -schema-encoding、upper/lower の規則は -transform-locale に従います。
+func ReadSchema(schemaFileName string, options Options) ([]Schema, error) {
+	if name, ok := strings.CutPrefix(schemaFileName, schemaEnvPrefix); ok {
+		schema, err := readSchemaEnv(name)
+		if err != nil {
+			return nil, err
+		}
+		return parseSchema(schema, options)
+	}
+
+	content, err := os.ReadFile(schemaFileName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open schema file: %s", err)
 	}
-	defer schemaFile.Close()
+	content, err = decodeText(content, options.SchemaEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file: %s", err)
+	}
 
-	schemaReader := csv.NewReader(schemaFile)
+	schemaReader := csv.NewReader(bytes.NewReader(content))
+	schemaReader.FieldsPerRecord = -1
 	schema, err := schemaReader.ReadAll()
 	if err != nil {
 		return nil, fmt.Errorf("failed to read schema file: %s", err)
 	}
+	return parseSchema(schema, options)
+}
 
+// parseSchema はスキーマの各行(ColumnFrom, DataTypeFrom, ColumnTo, DataTypeTo, 変換...)を解釈します。
+func parseSchema(schema [][]string, options Options) ([]Schema, error) {
 	var result []Schema
-	for _, column := range schema {
+	for i, column := range schema {
+		column, err := joinSplitTypes(column)
+		if err != nil {
+			return nil, fmt.Errorf("schema line %d: %s", i+1, err)
+		}
+		if len(column) < 4 {
+			return nil, fmt.Errorf("schema line %d: expected at least 4 fields, got %d", i+1, len(column))
+		}
+
+		var transforms []Transform
+		for _, spec := range column[4:] {
+			if spec == "" {
+				continue
+			}
+			transform, err := ParseTransform(spec, options.transformLocale)
+			if err != nil {
+				return nil, fmt.Errorf("schema line %d: %s", i+1, err)
+			}
+			transforms = append(transforms, transform)
+		}
+
+		var expression ColumnExpression
+		if isColumnExpression(column[0]) {
+			expression, err = ParseColumnExpression(column[0])
+			if err != nil {
+				return nil, fmt.Errorf("schema line %d: %s", i+1, err)
+			}
+		}
+
 		result = append(result, Schema{
 			ColumnFrom:   column[0],
 			DataTypeFrom: column[1],
 			ColumnTo:     column[2],
 			DataTypeTo:   column[3],
+			Transforms:   transforms,
+			Expression:   expression,
 		})
+
+		if err := checkBoolEnum(result[len(result)-1]); err != nil {
+			return nil, fmt.Errorf("schema line %d: %s", i+1, err)
+		}
 	}
 
 	return result, nil
 }
 
-func ReadInputFile(inputFileName string) (io.Reader, error) {
-	inputFile, err := os.Open(inputFileName)
+// joinSplitTypes はクォートし忘れた DECIMAL(18,2) や ENUM('a','b') のように、型のカンマで
+// 分かれてしまった DataTypeFrom/DataTypeTo のフィールドを、かっこが閉じるまでつなぎ直します。
+// クォートしていれば csv.Reader が1つのフィールドとして読むので、何もしません。
+func joinSplitTypes(record []string) ([]string, error) {
+	var result []string
+	for i := 0; i < len(record); i++ {
+		field := record[i]
+		if position := len(result); position == 1 || position == 3 {
+			for strings.Count(field, "(") > strings.Count(field, ")") {
+				if i+1 >= len(record) {
+					return nil, fmt.Errorf("unbalanced parentheses in data type %q", field)
+				}
+				i++
+				field += "," + record[i]
+			}
+		}
+		result = append(result, field)
+	}
+	return result, nil
+}
+
+type inputFile struct {
+	io.Reader
+	io.Closer
+}
+
+func ReadInputFile(inputFileName string) (io.ReadCloser, error) {
+	file, err := os.Open(inputFileName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open input file: %s", err)
 	}
-	defer inputFile.Close()
 
 	b := make([]byte, 3)
-	if _, err := inputFile.Read(b); err != nil {
+	n, err := io.ReadFull(file, b)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		file.Close()
 		return nil, fmt.Errorf("failed to read first 3 bytes of input file: %s", err)
 	}
-	b = removeBOM(b)
+	b = removeBOM(b[:n])
 
-	return io.MultiReader(bytes.NewReader(b), inputFile), nil
+	return &inputFile{Reader: io.MultiReader(bytes.NewReader(b), file), Closer: file}, nil
 }
 
-func ParseHeaders(reader io.Reader) ([]string, error) {
-	csvReader := csv.NewReader(reader)
+func ParseHeaders(csvReader *csv.Reader) ([]string, error) {
 	headers, err := csvReader.Read()
+	if err == io.EOF {
+		return nil, ErrEmptyInput
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to read headers from input file: %s", err)
 	}
@@ -131,50 +936,100 @@ func MapHeadersToSchema(headers []string, schema []Schema) map[string]int {
 	return headerIndexMap
 }
 
-func GenerateSQL(tableName string, schema []Schema, headerIndexMap map[string]int, reader io.Reader) string {
-	inputReader := csv.NewReader(reader)
-
+func GenerateSQL(tableName string, schema []Schema, headerIndexMap map[string]int, inputReader *csv.Reader, options Options) (string, *Report, error) {
 	var outputSQL strings.Builder
-	outputSQL.WriteString(fmt.Sprintf("INSERT INTO `%s` (", tableName))
+	report, err := WriteSQL(&outputSQL, tableName, schema, headerIndexMap, inputReader, options)
+	if err != nil {
+		return "", report, err
+	}
+	return outputSQL.String(), report, nil
+}
 
-	columns := make([]string, 0, len(schema))
-	for _, column := range schema {
-		columns = append(columns, fmt.Sprintf("`%s`", column.ColumnTo))
+// WriteSQL は入力を1行ずつ変換しながら INSERT 文を writer に書き出します。
+// -split-rows/-chunk-by-key-range 指定時、writer が PartWriter ならファイルを切り替えます。
+func WriteSQL(writer io.Writer, tableName string, schema []Schema, headerIndexMap map[string]int, inputReader *csv.Reader, options Options) (*Report, error) {
+	report := &Report{}
+	converter, err := newRowConverter(schema, headerIndexMap, options, report)
+	if err != nil {
+		return report, err
 	}
-	outputSQL.WriteString(strings.Join(columns, ", "))
-	outputSQL.WriteString(")\nVALUES\n")
 
-	for i := 0; ; i++ {
-		row, err := inputReader.Read()
-		if err == io.EOF {
-			outputSQL.WriteString(";\n")
-			break
+	output := &countingWriter{writer: writer}
+	statement, err := newInsertWriter(output, tableName, schema, options)
+	if err != nil {
+		return report, err
+	}
+	statement.parts, _ = writer.(PartWriter)
+
+	chunker, err := newKeyRangeChunker(schema, options)
+	if err != nil {
+		return report, err
+	}
+
+	splitRows := options.SplitRows
+	if options.CheckpointEvery > 0 {
+		splitRows = options.CheckpointEvery
+	}
+	part, lastRow := options.resume.Part+1, options.resume.Row
+
+	err = converter.each(inputReader, func(rowNumber int, values []Value) error {
+		if splitRows > 0 && report.Rows > 0 && report.Rows%splitRows == 0 {
+			if err := statement.nextPart(); err != nil {
+				return err
+			}
+			// 前のファイルを閉じ(リネームし)終えてから、そこまでを書き終えたと記録する
+			if options.CheckpointEvery > 0 && statement.parts != nil {
+				if err := writeCheckpoint(tableName, Checkpoint{Row: lastRow, Part: part, Every: options.CheckpointEvery}); err != nil {
+					return err
+				}
+			}
+			part++
 		}
-		if err != nil {
-			fmt.Printf("failed to read row %d: %s\n", i, err)
-			continue
+		lastRow = rowNumber
+		if chunker != nil {
+			next, err := chunker.next(rowNumber, values)
+			if err != nil {
+				return err
+			}
+			if next {
+				if err := statement.nextPart(); err != nil {
+					return err
+				}
+			}
 		}
 
-		if i > 0 {
-			outputSQL.WriteString(",\n")
+		if err := statement.add(rowNumber, values); err != nil {
+			return err
 		}
+		report.Rows++
 
-		outputSQL.WriteString("(")
-		for j, column := range schema {
-			headerIndex := headerIndexMap[column.ColumnFrom]
-			value := row[headerIndex]
-
-			convertedValue := convertData(value, column.DataTypeFrom, column.DataTypeTo)
+		if options.Progress != nil {
+			options.Progress.Update(report.Rows, output.n)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
 
-			outputSQL.WriteString(fmt.Sprintf("'%s'", convertedValue))
-			if j < len(schema)-1 {
-				outputSQL.WriteString(", ")
-			}
+	if report.Rows == 0 {
+		if options.Progress != nil {
+			options.Progress.Finish(0, 0)
 		}
-		outputSQL.WriteString(")")
+		if options.EmptyFileOK {
+			return report, nil
+		}
+		return report, ErrNoDataRows
+	}
+
+	if err := statement.close(); err != nil {
+		return report, err
+	}
+	if options.Progress != nil {
+		options.Progress.Finish(report.Rows, output.n)
 	}
 
-	return outputSQL.String()
+	return report, nil
 }
 
 func WriteSQLToFile(sql, tableName string) error {
@@ -188,20 +1043,3 @@ func removeBOM(data []byte) []byte {
 	}
 	return data
 }
-
-func convertData(value, srcType, destType string) string {
-	switch srcType {
-	case "int":
-		switch destType {
-		case "BIGINT":
-			return value // MySQLのBIGINTとして扱う
-		case "VARCHAR":
-			return value // 文字列として扱う
-		}
-	case "nvarchar", "varchar":
-		return value // 基本的にそのまま文字列として扱う
-	case "datetime":
-		return value // MySQLのDATETIMEに対応
-	}
-	return value
-}
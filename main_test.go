@@ -0,0 +1,399 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testOptions はコマンドラインと同じ既定値に flags を適用したオプションを返します。
+func testOptions(t testing.TB, flags ...string) Options {
+	t.Helper()
+	args, err := ParseArgs(append(append([]string{"convert"}, flags...), "t", "input.csv", "schema.csv"))
+	if err != nil {
+		t.Fatalf("ParseArgs(%q): %s", flags, err)
+	}
+	return args.Options
+}
+
+// testSchema はスキーマの CSV を ReadSchema と同じように解釈します。
+func testSchema(t testing.TB, schemaCSV string, options Options) []Schema {
+	t.Helper()
+	reader := csv.NewReader(strings.NewReader(schemaCSV))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := parseSchema(records, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+// runConvert は files を一時ディレクトリに書き、そこをカレントディレクトリにして
+// コマンドラインと同じく ParseArgs と ConvertFile を実行します。出力はテストの終わりまで
+// カレントディレクトリに残るので、readOutput で読めます。
+func runConvert(t *testing.T, files map[string]string, args ...string) error {
+	t.Helper()
+	writeFiles(t, files)
+	parsed, err := ParseArgs(append([]string{"convert"}, args...))
+	if err != nil {
+		return err
+	}
+	return ConvertFile(*parsed)
+}
+
+// writeFiles は一時ディレクトリをカレントディレクトリにして files を書きます。
+// カレントディレクトリはテストの終わりに元に戻します。
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readOutput は runConvert が書いたファイルを読みます。
+func readOutput(t *testing.T, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// generate はスキーマと入力の CSV からテーブル t の INSERT 文を作ります。
+func generate(t testing.TB, schemaCSV, inputCSV string, options Options) (string, *Report, error) {
+	t.Helper()
+	schema := testSchema(t, schemaCSV, options)
+	reader := csv.NewReader(strings.NewReader(inputCSV))
+	headers, err := ParseHeaders(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return GenerateSQL("t", schema, MapHeadersToSchema(headers, schema), reader, options)
+}
+
+func TestTablePrefixAndSuffix(t *testing.T) {
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{nil, "INSERT INTO `orders`"},
+		{[]string{"-table-prefix", "stg_"}, "INSERT INTO `stg_orders`"},
+		{[]string{"-table-suffix", "_new"}, "INSERT INTO `orders_new`"},
+		{[]string{"-table-prefix", "stg_", "-table-suffix", "_new"}, "INSERT INTO `stg_orders_new`"},
+	}
+	for _, tt := range tests {
+		options := testOptions(t, tt.flags...)
+		schema := testSchema(t, "id,int,id,INT\n", options)
+		reader := csv.NewReader(strings.NewReader("id\n1\n"))
+		headers, err := ParseHeaders(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sql, _, err := GenerateSQL("orders", schema, MapHeadersToSchema(headers, schema), reader, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(sql, tt.want) {
+			t.Errorf("%q: output starts with %q, want %q", tt.flags, strings.SplitN(sql, " (", 2)[0], tt.want)
+		}
+	}
+}
+
+func TestTableIdentifierLength(t *testing.T) {
+	table := strings.Repeat("a", 60)
+	tests := []struct {
+		flags   []string
+		wantErr bool
+	}{
+		{nil, false},
+		{[]string{"-table-prefix", "stg_"}, false}, // 64 文字ちょうど
+		{[]string{"-table-prefix", "stg_", "-table-suffix", "_x"}, true},
+		{[]string{"-table-suffix", "_archive"}, true},
+	}
+	for _, tt := range tests {
+		_, err := ParseArgs(append(append([]string{"convert"}, tt.flags...), table, "input.csv", "schema.csv"))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, want error %v", tt.flags, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEmptyInput(t *testing.T) {
+	schema := "id,int,id,INT\n"
+	tests := []struct {
+		name    string
+		input   string
+		flags   []string
+		wantErr error
+	}{
+		{"header only", "id\n", nil, ErrNoDataRows},
+		{"empty", "", nil, ErrEmptyInput},
+		{"BOM only", "\xEF\xBB\xBF", nil, ErrEmptyInput},
+		{"header only allowed", "id\n", []string{"-empty-file-ok"}, nil},
+		{"empty allowed", "", []string{"-empty-file-ok"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append(tt.flags, "t", "input.csv", "schema.csv")
+			err := runConvert(t, map[string]string{"input.csv": tt.input, "schema.csv": schema}, args...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			_, statErr := os.Stat("t.SQL")
+			if tt.wantErr != nil {
+				if statErr == nil {
+					t.Error("t.SQL was written for a failed conversion")
+				}
+				return
+			}
+			// 不正な "VALUES\n;" ではなく、空のファイルを作る
+			if sql := readOutput(t, "t.SQL"); sql != "" {
+				t.Errorf("t.SQL = %q, want an empty file", sql)
+			}
+		})
+	}
+}
+
+func TestDryRunSQL(t *testing.T) {
+	files := map[string]string{"input.csv": "id\n1\n2\n", "schema.csv": "id,int,id,INT\n"}
+	tests := [][]string{
+		{"-dry-run-sql"},
+		{"-dry-run-sql", "-split-rows", "1"},
+		{"-dry-run-sql", "-optimize", "-database", "shop"},
+	}
+	for _, flags := range tests {
+		t.Run(strings.Join(flags, " "), func(t *testing.T) {
+			if err := runConvert(t, files, append(flags, "t", "input.csv", "schema.csv")...); err != nil {
+				t.Fatal(err)
+			}
+			entries, err := os.ReadDir(".")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				if name := entry.Name(); name != "input.csv" && name != "schema.csv" {
+					t.Errorf("-dry-run-sql wrote %s", name)
+				}
+			}
+		})
+	}
+
+	for _, flags := range [][]string{{"-dry-run-sql", "-load-data"}, {"-dry-run-sql", "-prepared"}, {"-dry-run-sql", "-write-rejected"}, {"-dry-run-sql", "-checkpoint-every", "1"}} {
+		if _, err := ParseArgs(append(append([]string{"convert"}, flags...), "t", "input.csv", "schema.csv")); err == nil {
+			t.Errorf("ParseArgs accepted %q", flags)
+		}
+	}
+}
+
+func TestEmptyInputWithoutSQL(t *testing.T) {
+	// SQL を書かないモードでは、空の入力に -empty-file-ok を付けても空の SQL ファイルを作らない
+	tests := [][]string{
+		{"-dry-run-sql"},
+		{"-stats"},
+		{"-preview-diff", "current.csv"},
+		{"-validate-foreign-keys", "id=parents.csv"},
+	}
+	for _, flags := range tests {
+		for _, input := range []string{"", "id\n"} {
+			files := map[string]string{
+				"input.csv":   input,
+				"schema.csv":  "id,int,id,INT\n",
+				"current.csv": "id\n1\n",
+				"parents.csv": "id\n1\n",
+			}
+			args := append(append([]string{"-empty-file-ok"}, flags...), "t", "input.csv", "schema.csv")
+			if err := runConvert(t, files, args...); err != nil {
+				t.Errorf("%q with input %q: %s", flags, input, err)
+				continue
+			}
+			if _, err := os.Stat("t.SQL"); err == nil {
+				t.Errorf("%q with input %q wrote t.SQL", flags, input)
+			}
+		}
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		quote string
+		name  string
+		want  string
+	}{
+		{"", "order", "`order`"},
+		{IdentifierQuoteBacktick, "my`col", "`my``col`"},
+		{IdentifierQuoteBacktick, `say "hi"`, "`say \"hi\"`"},
+		{IdentifierQuoteDouble, "order", `"order"`},
+		{IdentifierQuoteDouble, `say "hi"`, `"say ""hi"""`},
+		{IdentifierQuoteDouble, "my`col", "\"my`col\""},
+	}
+	for _, tt := range tests {
+		options := Options{IdentifierQuote: tt.quote}
+		if got := options.QuoteIdentifier(tt.name); got != tt.want {
+			t.Errorf("%s: QuoteIdentifier(%q) = %s, want %s", tt.quote, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIdentifierQuoteEverywhere(t *testing.T) {
+	const schema = "id,int,id,INT\nname,nvarchar,\"na\"\"me\",VARCHAR(10)\n"
+	tests := []struct {
+		quote string
+		want  string
+	}{
+		{IdentifierQuoteBacktick, "USE `shop`;\nINSERT INTO `t` (`id`, `na\"me`)\nVALUES\n('1', 'a')\n" +
+			"ON DUPLICATE KEY UPDATE `id` = VALUES(`id`), `na\"me` = VALUES(`na\"me`);\nANALYZE TABLE `t`;\n"},
+		{IdentifierQuoteDouble, "USE \"shop\";\nINSERT INTO \"t\" (\"id\", \"na\"\"me\")\nVALUES\n('1', 'a')\n" +
+			"ON DUPLICATE KEY UPDATE \"id\" = VALUES(\"id\"), \"na\"\"me\" = VALUES(\"na\"\"me\");\nANALYZE TABLE \"t\";\n"},
+	}
+	for _, tt := range tests {
+		options := testOptions(t, "-identifier-quote", tt.quote, "-database", "shop", "-upsert", "-analyze")
+		sql, _, err := generate(t, schema, "id,name\n1,a\n", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sql != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.quote, sql, tt.want)
+		}
+	}
+	if _, err := ParseArgs([]string{"convert", "-identifier-quote", "bracket", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted -identifier-quote bracket")
+	}
+}
+
+func TestJoinSplitTypes(t *testing.T) {
+	tests := []struct {
+		record  []string
+		want    []string
+		wantErr bool
+	}{
+		{[]string{"price", "decimal", "price", "DECIMAL(10,2)"}, []string{"price", "decimal", "price", "DECIMAL(10,2)"}, false},
+		{[]string{"price", "decimal(18", "2)", "price", "DECIMAL(18", "2)", "trim"}, []string{"price", "decimal(18,2)", "price", "DECIMAL(18,2)", "trim"}, false},
+		{[]string{"kind", "nvarchar", "kind", "ENUM('a'", "'b'", "'c')"}, []string{"kind", "nvarchar", "kind", "ENUM('a','b','c')"}, false},
+		// ColumnFrom/ColumnTo のかっこはつなぎ直さない
+		{[]string{"=f(x", "int", "y", "INT"}, []string{"=f(x", "int", "y", "INT"}, false},
+		{[]string{"price", "decimal", "price", "DECIMAL(10"}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := joinSplitTypes(tt.record)
+		if (err != nil) != tt.wantErr || strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("joinSplitTypes(%q) = %q, %v; want %q", tt.record, got, err, tt.want)
+		}
+	}
+}
+
+func TestQuotedDataTypeInSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{"quoted", "price,decimal,price,\"DECIMAL(10,2)\"\n"},
+		{"quoted with transform", "price,decimal,price,\"DECIMAL(10,2)\",trim\n"},
+		{"unquoted", "price,decimal,price,DECIMAL(10,2)\n"},
+		{"both sides unquoted", "price,decimal(10,2),price,DECIMAL(10,2),trim\n"},
+	}
+	for _, tt := range tests {
+		schema := testSchema(t, tt.schema, Options{})
+		if len(schema) != 1 || schema[0].ColumnTo != "price" || schema[0].DataTypeTo != "DECIMAL(10,2)" {
+			t.Errorf("%s: schema %+v", tt.name, schema)
+			continue
+		}
+		sql, _, err := generate(t, tt.schema, "price\n 1.5 \n", testOptions(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(sql, "(1.50)") {
+			t.Errorf("%s: got\n%s", tt.name, sql)
+		}
+	}
+}
+
+func TestFailOnWarning(t *testing.T) {
+	files := map[string]string{"schema.csv": "id,int,id,INT\nname,nvarchar,name,VARCHAR(5)\n"}
+	tests := []struct {
+		input   string
+		flags   []string
+		wantErr bool
+	}{
+		{"id,name\n1,abcdefghijkl\n", nil, false},
+		{"id,name\n1,abcdefghijkl\n", []string{"-fail-on-warning"}, true},
+		{"id,name\n1,abc\n", []string{"-fail-on-warning"}, false},
+		{"id,name\n", []string{"-fail-on-warning", "-empty-file-ok"}, false}, // データ行がなく警告もない
+	}
+	for _, tt := range tests {
+		files["input.csv"] = tt.input
+		err := runConvert(t, files, append(tt.flags, "t", "input.csv", "schema.csv")...)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q with %q: error %v, wantErr %v", tt.input, tt.flags, err, tt.wantErr)
+		}
+		// エラーになっても出力は書き終えている
+		if _, statErr := os.Stat("t.SQL"); statErr != nil {
+			t.Errorf("%q with %q: %s", tt.input, tt.flags, statErr)
+		}
+	}
+}
+
+// TestFailOnWarningExitStatus はテストのバイナリを main() として実行し、終了コードを確かめます。
+func TestFailOnWarningExitStatus(t *testing.T) {
+	if os.Getenv("CONVERT_TEST_MAIN") == "1" {
+		os.Args = append([]string{"convert"}, strings.Fields(os.Getenv("CONVERT_TEST_ARGS"))...)
+		main()
+		os.Exit(0)
+	}
+
+	writeFiles(t, map[string]string{
+		"input.csv":  "id,name\n1,abcdefghijkl\n",
+		"schema.csv": "id,int,id,INT\nname,nvarchar,name,VARCHAR(5)\n",
+		// 変換せずにそのまま通す型だけのスキーマ
+		"clean.csv":        "born,code,ratio\n2023-04-05,JP,1.5\n",
+		"clean_schema.csv": "born,date,born,DATE\ncode,char(2),code,CHAR(2)\nratio,float,ratio,DOUBLE\n",
+	})
+	tests := []struct {
+		args     string
+		wantCode int
+	}{
+		{"t input.csv schema.csv", 0},
+		{"-fail-on-warning t input.csv schema.csv", 1},
+		{"-fail-on-warning t missing.csv schema.csv", 1},
+		{"-fail-on-warning t clean.csv clean_schema.csv", 0},
+		{"-fail-on-warning -report-unconverted-types t clean.csv clean_schema.csv", 1},
+	}
+	for _, tt := range tests {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestFailOnWarningExitStatus$")
+		cmd.Env = append(os.Environ(), "CONVERT_TEST_MAIN=1", "CONVERT_TEST_ARGS="+tt.args)
+		output, err := cmd.CombinedOutput()
+		code := 0
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if code != tt.wantCode {
+			t.Errorf("%s: exit status %d, want %d\n%s", tt.args, code, tt.wantCode, output)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maintenanceStatements は -optimize/-analyze 指定時に、すべての INSERT の後に書く文を返します。
+// OPTIMIZE TABLE は InnoDB ではテーブルを作り直すので、統計の更新(ANALYZE TABLE)はその後にします。
+// どちらも暗黙のコミットを伴うので、トランザクションの中では実行できません。
+func maintenanceStatements(tableName string, options Options) string {
+	table := options.QuoteIdentifier(options.TableIdentifier(tableName))
+
+	var sql strings.Builder
+	if options.Optimize {
+		sql.WriteString("\nOPTIMIZE TABLE " + table + ";")
+	}
+	if options.Analyze {
+		sql.WriteString("\nANALYZE TABLE " + table + ";")
+	}
+	return sql.String()
+}
+
+func validateMaintenanceOptions(options Options) error {
+	if (options.Analyze || options.Optimize) && options.Prepared {
+		return fmt.Errorf("-analyze and -optimize cannot be used with -prepared")
+	}
+	return nil
+}
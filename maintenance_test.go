@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaintenanceStatements(t *testing.T) {
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{nil, "('2');\n"},
+		{[]string{"-analyze"}, "('2');\nANALYZE TABLE `t`;\n"},
+		{[]string{"-optimize"}, "('2');\nOPTIMIZE TABLE `t`;\n"},
+		// OPTIMIZE TABLE がテーブルを作り直した後で統計を更新する
+		{[]string{"-analyze", "-optimize"}, "('2');\nOPTIMIZE TABLE `t`;\nANALYZE TABLE `t`;\n"},
+		{[]string{"-analyze", "-table-prefix", "stg_"}, "('2');\nANALYZE TABLE `stg_t`;\n"},
+		{[]string{"-analyze", "-upsert"}, "`id` = VALUES(`id`);\nANALYZE TABLE `t`;\n"},
+		{[]string{"-analyze", "-batch-markers"}, "('2');\n-- batch 1 end\nANALYZE TABLE `t`;\n"},
+		{[]string{"-analyze", "-trailing-newline=false"}, "('2');\nANALYZE TABLE `t`;"},
+	}
+	for _, tt := range tests {
+		sql, _, err := generate(t, "id,int,id,INT\n", "id\n1\n2\n", testOptions(t, tt.flags...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(sql, tt.want) {
+			t.Errorf("%q: output\n%s\nwant it to end with %q", tt.flags, sql, tt.want)
+		}
+	}
+}
+
+func TestMaintenanceAfterLastStatement(t *testing.T) {
+	sql, _, err := generate(t, "id,int,id,INT\n", "id\n1\n2\n3\n", testOptions(t, "-analyze", "-max-packet", "40"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(sql, "ANALYZE TABLE"); n != 1 || strings.Count(sql, "INSERT INTO") < 2 {
+		t.Errorf("ANALYZE TABLE appears %d times:\n%s", n, sql)
+	}
+
+	files := map[string]string{"input.csv": "id\n1\n2\n", "schema.csv": "id,int,id,INT\n"}
+	if err := runConvert(t, files, "-analyze", "-split-rows", "1", "t", "input.csv", "schema.csv"); err != nil {
+		t.Fatal(err)
+	}
+	// 分割した場合は最後のファイルにだけ書く
+	if sql := readOutput(t, "t_001.SQL"); strings.Contains(sql, "ANALYZE") {
+		t.Errorf("t_001.SQL contains ANALYZE TABLE:\n%s", sql)
+	}
+	if sql := readOutput(t, "t_002.SQL"); !strings.HasSuffix(sql, "ANALYZE TABLE `t`;\n") {
+		t.Errorf("t_002.SQL does not end with ANALYZE TABLE:\n%s", sql)
+	}
+}
+
+func TestMaintenanceOptionsInvalid(t *testing.T) {
+	for _, flags := range [][]string{
+		{"-analyze", "-prepared"},
+		{"-optimize", "-prepared"},
+		{"-analyze", "-values-only"},
+	} {
+		if _, err := ParseArgs(append(append([]string{"convert"}, flags...), "t", "input.csv", "schema.csv")); err == nil {
+			t.Errorf("ParseArgs accepted %q", flags)
+		}
+	}
+}
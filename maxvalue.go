@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+const (
+	MaxValueError    = "error"
+	MaxValueTruncate = "truncate"
+)
+
+func validateMaxValueOptions(options Options) error {
+	if options.MaxValueBytes < 0 {
+		return fmt.Errorf("invalid -max-value-bytes %d: must not be negative", options.MaxValueBytes)
+	}
+	switch options.MaxValueAction {
+	case MaxValueError, MaxValueTruncate:
+		return nil
+	}
+	return fmt.Errorf("invalid -max-value-action %q: must be %s or %s", options.MaxValueAction, MaxValueError, MaxValueTruncate)
+}
+
+// truncateBytes は value を n バイト以下に切り詰めます。UTF-8 の文字の途中では切りません。
+func truncateBytes(value string, n int) string {
+	if len(value) <= n {
+		return value
+	}
+	for n > 0 && !utf8.RuneStart(value[n]) {
+		n--
+	}
+	return value[:n]
+}
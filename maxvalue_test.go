@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTruncateBytes(t *testing.T) {
+	tests := []struct {
+		value string
+		n     int
+		want  string
+	}{
+		{"abcdef", 4, "abcd"},
+		{"abc", 4, "abc"},
+		{"日本語", 4, "日"}, // 3バイトの文字の途中では切らない
+		{"日本語", 6, "日本"},
+		{"日本語", 2, ""},
+		{"", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := truncateBytes(tt.value, tt.n); got != tt.want {
+			t.Errorf("truncateBytes(%q, %d) = %q, want %q", tt.value, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestMaxValueBytes(t *testing.T) {
+	const schema = "id,int,id,INT\nnote,nvarchar,note,TEXT\n"
+	// 閉じていないクォートで残りの入力が1つのフィールドになる
+	input := "id,note\n1,ok\n2,\"unterminated\n3,more\n4,rows\n" + strings.Repeat("x", 100) + "\"\n"
+	tests := []struct {
+		flags   []string
+		want    string
+		wantErr string
+	}{
+		{[]string{"-max-value-bytes", "20"}, "", "row 2, column note: value is 127 bytes, larger than -max-value-bytes 20; the input may contain an unterminated quote"},
+		{[]string{"-max-value-bytes", "20", "-max-value-action", "truncate"}, "('2', 'unterminated\\n3,more\\n')", ""},
+		{nil, "('2', 'unterminated", ""},
+	}
+	for _, tt := range tests {
+		sql, report, err := generate(t, schema, input, testOptions(t, tt.flags...))
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("%q: error %v, want %q", tt.flags, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %s", tt.flags, err)
+			continue
+		}
+		if !strings.Contains(sql, tt.want) {
+			t.Errorf("%q: got\n%s\nwant %s", tt.flags, sql, tt.want)
+		}
+		if tt.flags != nil && (len(report.Warnings) != 1 || !strings.HasSuffix(report.Warnings[0].Message, "; truncated")) {
+			t.Errorf("%q: warnings %v, want one truncation warning", tt.flags, report.Warnings)
+		}
+	}
+}
+
+func TestMaxValueOptionsInvalid(t *testing.T) {
+	for _, flags := range [][]string{{"-max-value-bytes", "-1"}, {"-max-value-action", "skip"}} {
+		if _, err := ParseArgs(append(append([]string{"convert"}, flags...), "t", "input.csv", "schema.csv")); err == nil {
+			t.Errorf("ParseArgs accepted %q", flags)
+		}
+	}
+}
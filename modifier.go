@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// insertPriorityModifiers は INSERT に1つだけ指定できる優先度の修飾子です。
+// DELAYED は MySQL 5.7 以降では無視されますが、古いサーバー向けに受け付けます。
+var insertPriorityModifiers = map[string]bool{"LOW_PRIORITY": true, "HIGH_PRIORITY": true, "DELAYED": true}
+
+// parseInsertModifiers は -insert-modifier をカンマまたは空白で区切り、MySQL の構文の順
+// (優先度、IGNORE の順)に並べ直して返します。
+func parseInsertModifiers(spec string) ([]string, error) {
+	var priority, ignore string
+	for _, modifier := range strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == ' ' }) {
+		modifier = strings.ToUpper(modifier)
+		switch {
+		case insertPriorityModifiers[modifier]:
+			if priority != "" && priority != modifier {
+				return nil, fmt.Errorf("invalid -insert-modifier %q: %s and %s cannot be used together", spec, priority, modifier)
+			}
+			priority = modifier
+		case modifier == "IGNORE":
+			ignore = modifier
+		default:
+			return nil, fmt.Errorf("invalid -insert-modifier %q: %s is not one of LOW_PRIORITY, HIGH_PRIORITY, DELAYED or IGNORE", spec, modifier)
+		}
+	}
+
+	var modifiers []string
+	for _, modifier := range []string{priority, ignore} {
+		if modifier != "" {
+			modifiers = append(modifiers, modifier)
+		}
+	}
+	return modifiers, nil
+}
+
+// insertKeyword は -insert-modifier を付けた INSERT キーワードを返します。
+func insertKeyword(options Options) string {
+	modifiers, _ := parseInsertModifiers(options.InsertModifier)
+	return strings.Join(append([]string{"INSERT"}, modifiers...), " ")
+}
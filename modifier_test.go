@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseInsertModifiers(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"ignore", "IGNORE", false},
+		{"IGNORE,LOW_PRIORITY", "LOW_PRIORITY IGNORE", false},
+		{"high_priority ignore", "HIGH_PRIORITY IGNORE", false},
+		{"delayed, delayed", "DELAYED", false},
+		{"LOW_PRIORITY,HIGH_PRIORITY", "", true},
+		{"QUICK", "", true},
+	}
+	for _, tt := range tests {
+		got, err := parseInsertModifiers(tt.spec)
+		if (err != nil) != tt.wantErr || strings.Join(got, " ") != tt.want {
+			t.Errorf("parseInsertModifiers(%q) = %q, %v; want %q", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestInsertModifierGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+	}{
+		{"modifier_ignore", []string{"-insert-modifier", "ignore"}},
+		{"modifier_low_priority_ignore", []string{"-insert-modifier", "IGNORE,LOW_PRIORITY", "-max-packet", "120"}},
+		{"modifier_upsert", []string{"-insert-modifier", "HIGH_PRIORITY", "-upsert", "-upsert-exclude", "id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _, err := generate(t, goldenSchema, goldenInput, testOptions(t, tt.flags...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name, sql)
+		})
+	}
+}
+
+func TestInsertModifierPrepared(t *testing.T) {
+	options := testOptions(t, "-prepared", "-insert-modifier", "ignore")
+	query, err := PreparedInsert("t", testSchema(t, "id,int,id,INT\n", options), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "INSERT IGNORE INTO `t` (`id`) VALUES (?)"; query != want {
+		t.Errorf("query %q, want %q", query, want)
+	}
+}
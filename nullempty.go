@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// nullEmptyGroups は -null-empty-for で型名の代わりに書けるまとまりです。
+var nullEmptyGroups = map[string][]string{
+	"numeric": {"TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "DECIMAL", "NUMERIC", "DEC", "FIXED", "FLOAT", "DOUBLE", "REAL", "BIT"},
+	"date":    {"DATE", "DATETIME", "TIMESTAMP", "TIME", "YEAR"},
+}
+
+// parseNullEmptyFor は -null-empty-for の型の一覧を、空の値を NULL にする転送先の型名の集合にします。
+// VARCHAR などを含めなければ、意図して空にした文字列は空のまま残ります。
+func parseNullEmptyFor(list string) (map[string]bool, error) {
+	if list == "" {
+		return nil, nil
+	}
+	types := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if group, ok := nullEmptyGroups[strings.ToLower(name)]; ok {
+			for _, typeName := range group {
+				types[typeName] = true
+			}
+			continue
+		}
+		typeName := strings.ToUpper(name)
+		if !mysqlTypes[typeName] {
+			return nil, fmt.Errorf("invalid -null-empty-for type %q: must be a MySQL type name, numeric or date", name)
+		}
+		types[typeName] = true
+	}
+	return types, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNullEmptyFor(t *testing.T) {
+	const schema = "id,int,id,INT\nprice,decimal,price,\"DECIMAL(10,2)\"\nborn,date,born,DATE\nname,nvarchar,name,VARCHAR(10)\n"
+	const input = "id,price,born,name\n,,,\n1,2,2023-01-02,a\n"
+	tests := []struct {
+		list string
+		want string
+	}{
+		{"", "('', '', '', '')"},
+		{"numeric", "(NULL, NULL, '', '')"},
+		{"date", "('', '', NULL, '')"},
+		{"numeric,date", "(NULL, NULL, NULL, '')"},
+		{"int, varchar", "(NULL, '', '', NULL)"},
+		{"DECIMAL", "('', NULL, '', '')"},
+	}
+	for _, tt := range tests {
+		var flags []string
+		if tt.list != "" {
+			flags = []string{"-null-empty-for", tt.list}
+		}
+		sql, _, err := generate(t, schema, input, testOptions(t, flags...))
+		if err != nil {
+			t.Errorf("%q: %s", tt.list, err)
+			continue
+		}
+		if !strings.Contains(sql, tt.want) {
+			t.Errorf("%q: got\n%s\nwant %s", tt.list, sql, tt.want)
+		}
+		// 空でない値はそのまま
+		if !strings.Contains(sql, "('1', 2.00, '2023-01-02', 'a')") {
+			t.Errorf("%q: non-empty row changed:\n%s", tt.list, sql)
+		}
+	}
+}
+
+func TestNullEmptyForInvalid(t *testing.T) {
+	if _, err := parseNullEmptyFor("numeric,STRING"); err == nil || !strings.Contains(err.Error(), `"STRING"`) {
+		t.Errorf("error %v, want STRING to be rejected", err)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+)
+
+// temporaryFileSuffix は書き終えるまでファイルを置いておく一時ファイルの接尾辞です。
+const temporaryFileSuffix = ".tmp"
+
+// PartWriter は -split-rows で出力を複数ファイルに分ける書き出し先です。
+// PartWriter でない io.Writer に分割出力した場合は、同じ出力に複数の INSERT 文が続きます。
+type PartWriter interface {
+	io.Writer
+	NextPart() error
+}
+
+// FileOutput は [テーブル名].SQL、分割時は [テーブル名]_001.SQL から順にファイルへ書き出します。
+// ファイルは最初に書き込んだときに作成します。
+type FileOutput struct {
+	tableName string
+	extension string
+	split     bool
+	atomic    bool // -checkpoint-every では .tmp に書き、閉じたときに本来の名前にリネームする
+	part      int
+	file      *os.File
+	buffer    *bufio.Writer
+	FileNames []string
+}
+
+func NewFileOutput(tableName, extension string, split bool) *FileOutput {
+	return &FileOutput{tableName: tableName, extension: extension, split: split, part: 1}
+}
+
+func (o *FileOutput) fileName() string {
+	if !o.split {
+		return o.tableName + o.extension
+	}
+	return fmt.Sprintf("%s_%03d%s", o.tableName, o.part, o.extension)
+}
+
+func (o *FileOutput) Write(p []byte) (int, error) {
+	if o.file == nil {
+		fileName := o.fileName()
+		createName := fileName
+		if o.atomic {
+			createName += temporaryFileSuffix
+		}
+		file, err := os.Create(createName)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create output file: %s", err)
+		}
+		o.file = file
+		o.buffer = bufio.NewWriter(file)
+		o.FileNames = append(o.FileNames, fileName)
+	}
+	return o.buffer.Write(p)
+}
+
+func (o *FileOutput) NextPart() error {
+	if err := o.Close(); err != nil {
+		return err
+	}
+	o.part++
+	return nil
+}
+
+func (o *FileOutput) Close() error {
+	if o.file == nil {
+		return nil
+	}
+	defer func() { o.file, o.buffer = nil, nil }()
+
+	if err := o.buffer.Flush(); err != nil {
+		o.file.Close()
+		return fmt.Errorf("failed to write output file: %s", err)
+	}
+	if err := o.file.Close(); err != nil || !o.atomic {
+		return err
+	}
+	fileName := o.FileNames[len(o.FileNames)-1]
+	if err := os.Rename(fileName+temporaryFileSuffix, fileName); err != nil {
+		return fmt.Errorf("failed to write output file: %s", err)
+	}
+	return nil
+}
+
+// abandon は書きかけのファイルをリネームせずに閉じ、一時ファイルのまま残します。
+func (o *FileOutput) abandon() {
+	if o.file != nil {
+		o.buffer.Flush()
+		o.file.Close()
+		o.file, o.buffer = nil, nil
+	}
+}
+
+type countingWriter struct {
+	writer io.Writer
+	n      int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.n += n
+	return n, err
+}
+
+const (
+	LineEndingsLF   = "lf"
+	LineEndingsCRLF = "crlf"
+)
+
+func validateLineEndings(lineEndings string) error {
+	switch lineEndings {
+	case "", LineEndingsLF, LineEndingsCRLF:
+		return nil
+	}
+	return fmt.Errorf("invalid -normalize-line-endings %q: must be %s or %s", lineEndings, LineEndingsLF, LineEndingsCRLF)
+}
+
+// lineEnding は -normalize-line-endings の改行を返します。指定がなければ生成したままの LF です。
+func lineEnding(lineEndings string) string {
+	if lineEndings == LineEndingsCRLF {
+		return "\r\n"
+	}
+	return "\n"
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeLineEndings(t *testing.T) {
+	files := map[string]string{
+		"input.csv":  "id,note\r\n1,\"a\r\nb\"\r\n2,c\r\n",
+		"schema.csv": "id,int,id,INT\nnote,nvarchar,note,VARCHAR(20)\n",
+	}
+	flags := []string{"-database", "shop", "-upsert", "-optimize", "-batch-markers"}
+	tests := []struct {
+		lineEndings string
+		newline     string
+	}{
+		{"", "\n"},
+		{LineEndingsLF, "\n"},
+		{LineEndingsCRLF, "\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lineEndings, func(t *testing.T) {
+			args := append(append([]string{}, flags...), "t", "input.csv", "schema.csv")
+			if tt.lineEndings != "" {
+				args = append([]string{"-normalize-line-endings", tt.lineEndings}, args...)
+			}
+			if err := runConvert(t, files, args...); err != nil {
+				t.Fatal(err)
+			}
+			sql := readOutput(t, "t.SQL")
+			lines := strings.SplitAfter(sql, "\n")
+			if len(lines) < 8 {
+				t.Fatalf("output has %d lines:\n%q", len(lines), sql)
+			}
+			for i, line := range lines[:len(lines)-1] {
+				if !strings.HasSuffix(line, tt.newline) || strings.Count(line, "\r") != strings.Count(tt.newline, "\r") {
+					t.Errorf("line %d = %q, want it to end with %q only", i+1, line, tt.newline)
+				}
+			}
+			// 値の中の改行(csv.Reader が LF に揃える)はエスケープするので、改行の指定に関係なく同じ表記になる
+			if !strings.Contains(sql, `('1', 'a\nb')`) {
+				t.Errorf("value was changed:\n%q", sql)
+			}
+		})
+	}
+}
+
+func TestNormalizeLineEndingsLoadData(t *testing.T) {
+	files := map[string]string{
+		"input.csv":  "id,note\n1,a\n",
+		"schema.csv": "id,int,id,INT\nnote,nvarchar,note,VARCHAR(20)\n",
+	}
+	tests := []struct {
+		lineEndings string
+		newline     string
+	}{
+		{LineEndingsLF, "\n"},
+		{LineEndingsCRLF, "\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lineEndings, func(t *testing.T) {
+			if err := runConvert(t, files, "-load-data", "-database", "shop", "-analyze", "-normalize-line-endings", tt.lineEndings, "t", "input.csv", "schema.csv"); err != nil {
+				t.Fatal(err)
+			}
+			sql := readOutput(t, "t.SQL")
+			lines := strings.SplitAfter(sql, "\n")
+			if len(lines) < 7 {
+				t.Fatalf("output has %d lines:\n%q", len(lines), sql)
+			}
+			for i, line := range lines[:len(lines)-1] {
+				if !strings.HasSuffix(line, tt.newline) || strings.Count(line, "\r") != strings.Count(tt.newline, "\r") {
+					t.Errorf("line %d = %q, want it to end with %q only", i+1, line, tt.newline)
+				}
+			}
+			// データファイルの行の区切りは -line-terminator のままで、LINES TERMINATED BY の表記も変えない
+			if !strings.Contains(sql, "LINES TERMINATED BY '\\n'"+tt.newline) {
+				t.Errorf("LINES TERMINATED BY was changed:\n%q", sql)
+			}
+		})
+	}
+}
+
+func TestLineEnding(t *testing.T) {
+	tests := map[string]string{"": "\n", LineEndingsLF: "\n", LineEndingsCRLF: "\r\n"}
+	for lineEndings, want := range tests {
+		if got := lineEnding(lineEndings); got != want {
+			t.Errorf("lineEnding(%q) = %q, want %q", lineEndings, got, want)
+		}
+	}
+	if _, err := ParseArgs([]string{"convert", "-normalize-line-endings", "cr", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted -normalize-line-endings cr")
+	}
+}
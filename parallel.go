@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"sync"
+)
+
+// convertJob は -parallel-within-file で変換する1行分の処理です。
+// done が閉じられた後に values, report, err を読みます。
+type convertJob struct {
+	rowNumber int
+	row       []string
+	readErr   error // 読み込みで起きたエラー。errRejectedRow なら行を飛ばす
+
+	values []Value
+	report Report
+	err    error
+	done   chan struct{}
+}
+
+// eachParallel は each と同じ結果を、変換だけを複数の goroutine で行って求めます。
+// 読み込みは1つの goroutine で順に行い、変換済みの行は ordered から入力の順に取り出すので、
+// 出力の順序、警告の順序、エラーで中断する位置は逐次の場合と変わりません。
+func (c *rowConverter) eachParallel(inputReader *csv.Reader, fn func(rowNumber int, values []Value) error) error {
+	workers := c.options.ParallelWithinFile
+	work := make(chan *convertJob, workers)
+	ordered := make(chan *convertJob, workers*4) // 並べ直しを待てる行数の上限
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range work {
+				job.values, job.err = c.convert(job.rowNumber, job.row, &job.report)
+				close(job.done)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(ordered)
+		defer close(work)
+		reader := c.newRowReader(inputReader)
+		for {
+			rowNumber, row, err := reader.next()
+			if err == io.EOF {
+				return
+			}
+			job := &convertJob{rowNumber: rowNumber, row: row, readErr: err, done: make(chan struct{})}
+			select {
+			case ordered <- job:
+			case <-stop:
+				return
+			}
+			if err != nil {
+				close(job.done)
+				if !errors.Is(err, errRejectedRow) {
+					return
+				}
+				continue
+			}
+			select {
+			case work <- job:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	err := c.collect(ordered, fn)
+	close(stop)
+	for range ordered {
+		// 読み込みの goroutine が終わるまで読み捨てる
+	}
+	wg.Wait()
+	return err
+}
+
+// collect は変換済みの行を入力の順に受け取り、each と同じように fn に渡します。
+func (c *rowConverter) collect(ordered <-chan *convertJob, fn func(rowNumber int, values []Value) error) error {
+	for job := range ordered {
+		<-job.done
+		switch {
+		case errors.Is(job.readErr, errRejectedRow):
+			c.report.RejectedRows++
+			continue
+		case job.readErr != nil:
+			return job.readErr
+		}
+
+		c.report.merge(&job.report, c.options.TransformAudit)
+		if job.err != nil {
+			return job.err
+		}
+		if err := c.emit(job.rowNumber, job.values, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
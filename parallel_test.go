@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const parallelSchema = `id,int,id,INT
+name,nvarchar,name,VARCHAR(8),trim
+flag,tinyint,flag,TINYINT
+price,decimal,price,"DECIMAL(10,2)"
+created,datetime,created,DATETIME(3)
+`
+
+// parallelInput は警告と重複を含む rows 行の入力を作ります。broken なら読めない行も混ぜます。
+func parallelInput(rows int, broken bool) string {
+	var input strings.Builder
+	input.WriteString("id,name,flag,price,created\n")
+	for i := 1; i <= rows; i++ {
+		switch {
+		case broken && i%97 == 0:
+			fmt.Fprintf(&input, "%d,broken\n", i) // フィールドが足りない
+		case i%31 == 0:
+			fmt.Fprintf(&input, "%d, a name longer than eight ,200,1E+%d,2023-01-02 03:04:05.9999\n", i-1, i%5)
+		default:
+			fmt.Fprintf(&input, "%d,  n%d ,%d,%d.%02d,2023-01-02 03:04:%02d.%d\n", i, i, i%128, i, i%100, i%60, i)
+		}
+	}
+	return input.String()
+}
+
+func TestParallelWithinFileMatchesSequential(t *testing.T) {
+	input := parallelInput(2000, true)
+	tests := [][]string{
+		nil,
+		{"-max-packet", "2000"},
+		{"-dedupe-key", "id", "-transform-audit", "3"},
+		{"-fractional-seconds", "round", "-batch-markers"},
+	}
+	for _, flags := range tests {
+		sequentialSQL, sequentialReport, sequentialErr := generate(t, parallelSchema, input, testOptions(t, flags...))
+		if sequentialErr != nil {
+			t.Fatalf("%q: %s", flags, sequentialErr)
+		}
+		for _, workers := range []int{2, 4, 8} {
+			options := testOptions(t, append(flags, "-parallel-within-file", strconv.Itoa(workers))...)
+			sql, report, err := generate(t, parallelSchema, input, options)
+			if err != nil {
+				t.Fatalf("%q with %d workers: %s", flags, workers, err)
+			}
+			if sql != sequentialSQL {
+				t.Errorf("%q with %d workers: output differs from the sequential output", flags, workers)
+			}
+			if !reflect.DeepEqual(report, sequentialReport) {
+				t.Errorf("%q with %d workers: report differs\n got %+v\nwant %+v", flags, workers, report, sequentialReport)
+			}
+		}
+	}
+}
+
+func TestParallelWithinFileError(t *testing.T) {
+	// 入力の途中のエラーでは、逐次の場合と同じ行で中断する
+	input := parallelInput(500, true) + "501" + strings.Repeat(",x", 40) + "\n" + parallelInput(100, false)[len("id,name,flag,price,created\n"):]
+	_, _, want := generate(t, parallelSchema, input, testOptions(t))
+	if want == nil {
+		t.Fatal("sequential conversion succeeded, want an error")
+	}
+	for _, workers := range []int{2, 8} {
+		_, _, err := generate(t, parallelSchema, input, testOptions(t, "-parallel-within-file", strconv.Itoa(workers)))
+		if err == nil || err.Error() != want.Error() {
+			t.Errorf("%d workers: error %v, want %v", workers, err, want)
+		}
+	}
+}
+
+func BenchmarkParallelWithinFile(b *testing.B) {
+	input := parallelInput(20000, false)
+	for _, workers := range []int{0, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			options := testOptions(b, "-parallel-within-file", strconv.Itoa(workers))
+			b.SetBytes(int64(len(input)))
+			for i := 0; i < b.N; i++ {
+				if _, _, err := generate(b, parallelSchema, input, options); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import "strings"
+
+// PassthroughUnmapped は -passthrough-unmapped 指定時に、スキーマにない入力のカラムを
+// 同じ名前のカラムとしてスキーマに加えます。型は変換しない文字列(nvarchar -> TEXT)として扱います。
+// 既存の転送先カラムや生成カラムと同じ名前のカラムは加えず、警告を返します。
+// -checksum-column のカラムは末尾に残します。
+func PassthroughUnmapped(schema []Schema, headers []string, generated map[string]bool) ([]Schema, []Warning) {
+	mapped := make(map[string]bool, len(schema))
+	destinations := make(map[string]bool, len(schema))
+	for _, column := range schema {
+		if column.Expression == nil {
+			mapped[column.ColumnFrom] = true
+		}
+		destinations[strings.ToLower(column.ColumnTo)] = true
+	}
+
+	var added []Schema
+	var warnings []Warning
+	for _, header := range headers {
+		switch {
+		case mapped[header], generated[strings.ToLower(header)]:
+			continue
+		case destinations[strings.ToLower(header)]:
+			warnings = append(warnings, Warning{Column: header, Message: "unmapped input column is not passed through because the schema already has a destination column with this name"})
+			continue
+		}
+		mapped[header] = true
+		destinations[strings.ToLower(header)] = true
+		added = append(added, Schema{ColumnFrom: header, DataTypeFrom: "nvarchar", ColumnTo: header, DataTypeTo: "TEXT"})
+	}
+
+	result := make([]Schema, 0, len(schema)+len(added))
+	var checksum []Schema
+	for _, column := range schema {
+		if column.Checksum != "" {
+			checksum = append(checksum, column)
+			continue
+		}
+		result = append(result, column)
+	}
+	result = append(result, added...)
+	return append(result, checksum...), warnings
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPassthroughUnmapped(t *testing.T) {
+	schema := testSchema(t, "UserID,int,id,INT\n=first + last,nvarchar,name,VARCHAR(20)\n", Options{})
+	schema = append(schema, Schema{DataTypeFrom: "varchar", ColumnTo: "row_hash", DataTypeTo: "CHAR(40)", Checksum: ChecksumSHA1})
+	headers := []string{"UserID", "first", "last", "memo", "Name", "total"}
+
+	got, warnings := PassthroughUnmapped(schema, headers, parseColumnList("total"))
+	var columns []string
+	for _, column := range got {
+		columns = append(columns, column.ColumnTo+":"+column.DataTypeTo)
+	}
+	// 式で使うカラムもスキーマに対応するカラムがなければ渡す。チェックサムのカラムは末尾に残す
+	want := "id:INT,name:VARCHAR(20),first:TEXT,last:TEXT,memo:TEXT,row_hash:CHAR(40)"
+	if strings.Join(columns, ",") != want {
+		t.Errorf("columns %s, want %s", strings.Join(columns, ","), want)
+	}
+	if len(warnings) != 1 || warnings[0].Column != "Name" {
+		t.Errorf("warnings %v, want one for Name", warnings)
+	}
+}
+
+func TestPassthroughUnmappedFile(t *testing.T) {
+	files := map[string]string{
+		"input.csv":  "id,memo,name\n1,it's,a\n",
+		"schema.csv": "id,int,id,INT\nname,nvarchar,name,VARCHAR(10)\n",
+	}
+	if err := runConvert(t, files, "-passthrough-unmapped", "t", "input.csv", "schema.csv"); err != nil {
+		t.Fatal(err)
+	}
+	want := "INSERT INTO `t` (`id`, `name`, `memo`)\nVALUES\n('1', 'a', 'it\\'s');\n"
+	if got := readOutput(t, "t.SQL"); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var charsetNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+var sqlModeNamePattern = regexp.MustCompile(`^[A-Z_]+$`)
+
+// sqlModeNoBackslashEscapes を -sql-mode に含めると、文字列リテラルの \ がエスケープ文字でなくなります。
+const sqlModeNoBackslashEscapes = "NO_BACKSLASH_ESCAPES"
+
+// preamble は INSERT の前に出力するセッション設定の文を返します。
+// 出力順は次のとおりです。sql_mode は後の文の解釈を変えるので、ANSI_QUOTES で "db" と書いた USE
+// なども読めるよう最初に設定します。
+//
+//  1. SET SESSION sql_mode
+//  2. USE
+//  3. SET NAMES
+func preamble(options Options) string {
+	var sql strings.Builder
+
+	if options.SQLMode != "" {
+		sql.WriteString("SET SESSION sql_mode = '" + strings.Join(sqlModes(options.SQLMode), ",") + "';\n")
+	}
+
+	if options.Database != "" {
+		sql.WriteString("USE " + options.QuoteIdentifier(options.Database) + ";\n")
+	}
+
+	if options.SetNames != "" {
+		sql.WriteString("SET NAMES " + options.SetNames)
+		if options.Collation != "" {
+			sql.WriteString(" COLLATE " + options.Collation)
+		}
+		sql.WriteString(";\n")
+	}
+
+	return sql.String()
+}
+
+func validatePreambleOptions(options Options) error {
+	if n := utf8.RuneCountInString(options.Database); n > maxIdentifierLength {
+		return fmt.Errorf("database name %q is %d characters long, exceeding the MySQL limit of %d", options.Database, n, maxIdentifierLength)
+	}
+	if options.SetNames != "" && !charsetNamePattern.MatchString(options.SetNames) {
+		return fmt.Errorf("invalid -set-names %q: must be a character set name", options.SetNames)
+	}
+	if options.Collation != "" {
+		if options.SetNames == "" {
+			return fmt.Errorf("-collation requires -set-names")
+		}
+		if !charsetNamePattern.MatchString(options.Collation) {
+			return fmt.Errorf("invalid -collation %q: must be a collation name", options.Collation)
+		}
+	}
+
+	for _, mode := range sqlModes(options.SQLMode) {
+		if !sqlModeNamePattern.MatchString(mode) {
+			return fmt.Errorf("invalid -sql-mode %q: %q is not an sql_mode name", options.SQLMode, mode)
+		}
+	}
+	if options.SQLMode != "" && options.Prepared {
+		return fmt.Errorf("-sql-mode cannot be used with -prepared")
+	}
+	// LOAD DATA の FIELDS ESCAPED BY '\\' などの句は、バックスラッシュがエスケープ文字である前提で書いている
+	if options.noBackslashEscapes && options.LoadData {
+		return fmt.Errorf("-sql-mode %s cannot be used with -load-data", sqlModeNoBackslashEscapes)
+	}
+	return nil
+}
+
+// sqlModes は -sql-mode をカンマで区切り、大文字にそろえて返します。
+func sqlModes(list string) []string {
+	if list == "" {
+		return nil
+	}
+	var modes []string
+	for _, mode := range strings.Split(list, ",") {
+		modes = append(modes, strings.ToUpper(strings.TrimSpace(mode)))
+	}
+	return modes
+}
+
+// hasSQLMode は -sql-mode に mode が含まれるかどうかを返します。
+func hasSQLMode(list, mode string) bool {
+	for _, m := range sqlModes(list) {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetNamesPreamble(t *testing.T) {
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{nil, "INSERT INTO"},
+		{[]string{"-set-names", "utf8mb4"}, "SET NAMES utf8mb4;\nINSERT INTO"},
+		{[]string{"-set-names", "utf8mb4", "-collation", "utf8mb4_unicode_ci"}, "SET NAMES utf8mb4 COLLATE utf8mb4_unicode_ci;\nINSERT INTO"},
+	}
+	for _, tt := range tests {
+		sql, _, err := generate(t, "id,int,id,INT\n", "id\n1\n", testOptions(t, tt.flags...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(sql, tt.want) {
+			t.Errorf("%q: output\n%s\nwant it to start with %q", tt.flags, sql, tt.want)
+		}
+		if n := strings.Count(sql, "SET NAMES"); n > 1 {
+			t.Errorf("%q: SET NAMES appears %d times", tt.flags, n)
+		}
+	}
+}
+
+func TestSetNamesInEverySplitFile(t *testing.T) {
+	files := map[string]string{"input.csv": "id\n1\n2\n", "schema.csv": "id,int,id,INT\n"}
+	if err := runConvert(t, files, "-set-names", "utf8mb4", "-split-rows", "1", "t", "input.csv", "schema.csv"); err != nil {
+		t.Fatal(err)
+	}
+	// 分割したファイルは別々のセッションで流すこともあるので、それぞれの先頭に書く
+	for _, name := range []string{"t_001.SQL", "t_002.SQL"} {
+		if sql := readOutput(t, name); !strings.HasPrefix(sql, "SET NAMES utf8mb4;\n") {
+			t.Errorf("%s does not start with SET NAMES:\n%s", name, sql)
+		}
+	}
+}
+
+func TestSetNamesValidation(t *testing.T) {
+	tests := [][]string{
+		{"-collation", "utf8mb4_bin"},
+		{"-set-names", "utf8mb4; DROP TABLE t"},
+		{"-set-names", "utf8mb4", "-collation", "bin'"},
+	}
+	for _, flags := range tests {
+		if _, err := ParseArgs(append(append([]string{"convert"}, flags...), "t", "input.csv", "schema.csv")); err == nil {
+			t.Errorf("ParseArgs accepted %q", flags)
+		}
+	}
+}
+
+func TestUseDatabase(t *testing.T) {
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{[]string{"-database", "shop"}, "USE `shop`;\nINSERT INTO"},
+		{[]string{"-database", "my`db"}, "USE `my``db`;\nINSERT INTO"},
+		{[]string{"-database", "shop", "-set-names", "utf8mb4"}, "USE `shop`;\nSET NAMES utf8mb4;\nINSERT INTO"},
+		{[]string{"-database", "shop", "-batch-markers"}, "USE `shop`;\n-- batch 1 start\nINSERT INTO"},
+		// 文を分けても USE は先頭に1回だけ書く
+		{[]string{"-database", "shop", "-max-packet", "60"}, "USE `shop`;\nINSERT INTO"},
+	}
+	for _, tt := range tests {
+		sql, _, err := generate(t, "id,int,id,INT\n", "id\n1\n2\n", testOptions(t, tt.flags...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(sql, tt.want) {
+			t.Errorf("%q: output\n%s\nwant it to start with %q", tt.flags, sql, tt.want)
+		}
+		if n := strings.Count(sql, "USE "); n != 1 {
+			t.Errorf("%q: USE appears %d times", tt.flags, n)
+		}
+	}
+}
+
+func TestUseDatabaseInEverySplitFile(t *testing.T) {
+	files := map[string]string{"input.csv": "id\n1\n2\n", "schema.csv": "id,int,id,INT\n"}
+	if err := runConvert(t, files, "-database", "shop", "-split-rows", "1", "t", "input.csv", "schema.csv"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"t_001.SQL", "t_002.SQL"} {
+		if sql := readOutput(t, name); !strings.HasPrefix(sql, "USE `shop`;\nINSERT INTO") {
+			t.Errorf("%s does not start with USE:\n%s", name, sql)
+		}
+	}
+}
+
+func TestUseDatabaseTooLong(t *testing.T) {
+	if _, err := ParseArgs([]string{"convert", "-database", strings.Repeat("d", maxIdentifierLength+1), "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted a database name longer than the MySQL limit")
+	}
+}
+
+func TestSQLModePreamble(t *testing.T) {
+	const schema = "name,nvarchar,name,VARCHAR(20)\n"
+	const input = "name\n\"O'Brien\\\nx\"\n"
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{nil, "INSERT INTO `t` (`name`)\nVALUES\n('O\\'Brien\\\\\\nx');\n"},
+		{[]string{"-sql-mode", "strict_trans_tables, NO_BACKSLASH_ESCAPES"},
+			"SET SESSION sql_mode = 'STRICT_TRANS_TABLES,NO_BACKSLASH_ESCAPES';\nINSERT INTO `t` (`name`)\nVALUES\n('O''Brien\\\nx');\n"},
+		// 改行を揃えても、エスケープしない値の中の改行はそのまま残す
+		{[]string{"-sql-mode", "NO_BACKSLASH_ESCAPES", "-normalize-line-endings", "crlf"},
+			"SET SESSION sql_mode = 'NO_BACKSLASH_ESCAPES';\r\nINSERT INTO `t` (`name`)\r\nVALUES\r\n('O''Brien\\\nx');\r\n"},
+		{[]string{"-sql-mode", "ANSI_QUOTES", "-database", "shop", "-set-names", "utf8mb4"},
+			"SET SESSION sql_mode = 'ANSI_QUOTES';\nUSE `shop`;\nSET NAMES utf8mb4;\nINSERT INTO `t` (`name`)\nVALUES\n('O\\'Brien\\\\\\nx');\n"},
+		// "db" を識別子として読ませる ANSI_QUOTES は USE より前に有効にする
+		{[]string{"-identifier-quote", "double", "-sql-mode", "ANSI_QUOTES", "-database", "db"},
+			"SET SESSION sql_mode = 'ANSI_QUOTES';\nUSE \"db\";\nINSERT INTO \"t\" (\"name\")\nVALUES\n('O\\'Brien\\\\\\nx');\n"},
+	}
+	for _, tt := range tests {
+		sql, _, err := generate(t, schema, input, testOptions(t, tt.flags...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sql != tt.want {
+			t.Errorf("%q:\n got %q\nwant %q", tt.flags, sql, tt.want)
+		}
+	}
+}
+
+func TestSQLModeValidation(t *testing.T) {
+	tests := [][]string{
+		{"-sql-mode", "ANSI'; DROP TABLE t; --"},
+		{"-sql-mode", "NO_BACKSLASH_ESCAPES", "-load-data"},
+		{"-sql-mode", "ANSI", "-prepared"},
+	}
+	for _, flags := range tests {
+		if _, err := ParseArgs(append(append([]string{"convert"}, flags...), "t", "input.csv", "schema.csv")); err == nil {
+			t.Errorf("ParseArgs accepted %q", flags)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const preparedFileExtension = ".jsonl"
+
+const (
+	PlaceholderQuestion = "question" // ? (MySQL)
+	PlaceholderDollar   = "dollar"   // $1, $2, ... (pgx など)
+	PlaceholderNamed    = "named"    // :column (sqlx などの名前付き)
+)
+
+var placeholderNamePattern = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+func validatePreparedOptions(options Options) error {
+	switch options.PlaceholderDialect {
+	case PlaceholderQuestion, PlaceholderDollar, PlaceholderNamed:
+	default:
+		return fmt.Errorf("invalid -placeholder-dialect %q: must be %s, %s or %s", options.PlaceholderDialect, PlaceholderQuestion, PlaceholderDollar, PlaceholderNamed)
+	}
+
+	if options.Prepared && (options.LoadData || options.SplitRows > 0) {
+		return fmt.Errorf("-prepared cannot be used with -load-data or -split-rows")
+	}
+	// 値はクォートせずに JSON の文字列や数値として渡すので、クォートの指定は意味を持たない
+	if options.Prepared && (options.QuoteAll || options.QuoteNone) {
+		return fmt.Errorf("-prepared cannot be used with -quote-all or -quote-none")
+	}
+	return nil
+}
+
+// placeholderNames は名前付きプレースホルダーに使う名前を ColumnTo から作ります。
+// 英数字と _ 以外は _ に置き換え、重複した名前には連番を付けます。
+func placeholderNames(schema []Schema) []string {
+	names := make([]string, 0, len(schema))
+	used := make(map[string]int)
+	for _, column := range schema {
+		name := strings.Trim(placeholderNamePattern.ReplaceAllString(column.ColumnTo, "_"), "_")
+		if name == "" {
+			name = "column"
+		}
+		used[name]++
+		if n := used[name]; n > 1 {
+			name = fmt.Sprintf("%s_%d", name, n)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// PreparedInsert はプレースホルダー付きの INSERT 文を -placeholder-dialect の形式で返します。
+func PreparedInsert(tableName string, schema []Schema, options Options) (string, error) {
+	columns := make([]string, 0, len(schema))
+	placeholders := make([]string, 0, len(schema))
+	for i, column := range schema {
+		columns = append(columns, options.QuoteIdentifier(column.ColumnTo))
+		switch options.PlaceholderDialect {
+		case PlaceholderDollar:
+			placeholders = append(placeholders, fmt.Sprintf("$%d", i+1))
+		case PlaceholderNamed:
+			placeholders = append(placeholders, ":"+placeholderNames(schema)[i])
+		default:
+			placeholders = append(placeholders, "?")
+		}
+	}
+
+	footer, err := onDuplicateKeyUpdate(schema, options)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s INTO %s (%s) VALUES (%s)%s",
+		insertKeyword(options), options.QuoteIdentifier(options.TableIdentifier(tableName)), strings.Join(columns, ", "), strings.Join(placeholders, ", "), footer), nil
+}
+
+// WritePrepared は1行目に {"query": ...} としてプレースホルダー付きの INSERT 文を、
+// 以降の各行に {"args": ...} としてその行の値を JSON Lines で書き出します。
+// 名前付きの形式では args はプレースホルダー名をキーにしたオブジェクト、それ以外は配列です。
+func WritePrepared(writer io.Writer, tableName string, schema []Schema, headerIndexMap map[string]int, inputReader *csv.Reader, options Options) (*Report, error) {
+	report := &Report{}
+	converter, err := newRowConverter(schema, headerIndexMap, options, report)
+	if err != nil {
+		return report, err
+	}
+
+	query, err := PreparedInsert(tableName, schema, options)
+	if err != nil {
+		return report, err
+	}
+	names := placeholderNames(schema)
+
+	output := &countingWriter{writer: writer}
+	encoder := json.NewEncoder(output)
+	encoder.SetEscapeHTML(false)
+
+	err = converter.each(inputReader, func(rowNumber int, values []Value) error {
+		if report.Rows == 0 {
+			if err := encoder.Encode(map[string]string{"query": query}); err != nil {
+				return err
+			}
+		}
+
+		args := make([]any, 0, len(values))
+		for j, value := range values {
+			switch {
+			case value == nullValue:
+				args = append(args, nil)
+			case value.Kind == KeywordValue:
+				return fmt.Errorf("row %d, column %s: %s cannot be bound as a parameter", rowNumber, schema[j].ColumnTo, value.Text)
+			case value.Kind == NumberValue:
+				args = append(args, json.Number(value.Text))
+			default:
+				args = append(args, value.Text)
+			}
+		}
+
+		var line any = args
+		if options.PlaceholderDialect == PlaceholderNamed {
+			named := make(map[string]any, len(args))
+			for j, arg := range args {
+				named[names[j]] = arg
+			}
+			line = named
+		}
+		if err := encoder.Encode(map[string]any{"args": line}); err != nil {
+			return err
+		}
+		report.Rows++
+
+		if options.Progress != nil {
+			options.Progress.Update(report.Rows, output.n)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	if report.Rows == 0 {
+		if options.Progress != nil {
+			options.Progress.Finish(0, 0)
+		}
+		if options.EmptyFileOK {
+			return report, nil
+		}
+		return report, ErrNoDataRows
+	}
+
+	if options.Progress != nil {
+		options.Progress.Finish(report.Rows, output.n)
+	}
+	return report, nil
+}
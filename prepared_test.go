@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestWritePrepared(t *testing.T) {
+	const schema = "id,int,id,INT\nunit price,decimal(10,2),unit price,DECIMAL(10,2)\nnote,nvarchar,note,VARCHAR(20)\n"
+	const input = "id,unit price,note\n1,2.50,a'b\n2,3,\n"
+	tests := []struct {
+		dialect string
+		want    string
+	}{
+		{PlaceholderQuestion, `{"query":"INSERT INTO ` + "`t` (`id`, `unit price`, `note`)" + ` VALUES (?, ?, ?)"}
+{"args":["1",2.50,"a'b"]}
+{"args":["2",3.00,null]}
+`},
+		{PlaceholderDollar, `{"query":"INSERT INTO ` + "`t` (`id`, `unit price`, `note`)" + ` VALUES ($1, $2, $3)"}
+{"args":["1",2.50,"a'b"]}
+{"args":["2",3.00,null]}
+`},
+		{PlaceholderNamed, `{"query":"INSERT INTO ` + "`t` (`id`, `unit price`, `note`)" + ` VALUES (:id, :unit_price, :note)"}
+{"args":{"id":"1","note":"a'b","unit_price":2.50}}
+{"args":{"id":"2","note":null,"unit_price":3.00}}
+`},
+	}
+	for _, tt := range tests {
+		options := testOptions(t, "-prepared", "-placeholder-dialect", tt.dialect, "-null-empty-for", "VARCHAR")
+		schema := testSchema(t, schema, options)
+		reader := csv.NewReader(strings.NewReader(input))
+		headers, err := ParseHeaders(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var output bytes.Buffer
+		report, err := WritePrepared(&output, "t", schema, MapHeadersToSchema(headers, schema), reader, options)
+		if err != nil {
+			t.Errorf("%s: %s", tt.dialect, err)
+			continue
+		}
+		if got := output.String(); got != tt.want || report.Rows != 2 {
+			t.Errorf("%s: %d rows\n%s\nwant\n%s", tt.dialect, report.Rows, got, tt.want)
+		}
+	}
+}
+
+func TestPlaceholderNames(t *testing.T) {
+	schema := []Schema{{ColumnTo: "order id"}, {ColumnTo: "order-id"}, {ColumnTo: "名前"}, {ColumnTo: "_x_"}}
+	want := []string{"order_id", "order_id_2", "column", "x"}
+	got := placeholderNames(schema)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("placeholderNames = %q, want %q", got, want)
+	}
+}
+
+func TestPreparedOptionsInvalid(t *testing.T) {
+	tests := [][]string{
+		{"-prepared", "-placeholder-dialect", "at"},
+		{"-prepared", "-load-data"},
+		{"-prepared", "-split-rows", "10"},
+		{"-prepared", "-quote-all"},
+		{"-prepared", "-quote-none"},
+	}
+	for _, flags := range tests {
+		if _, err := ParseArgs(append(append([]string{"convert"}, flags...), "t", "input.csv", "schema.csv")); err == nil {
+			t.Errorf("ParseArgs accepted %q", flags)
+		}
+	}
+}
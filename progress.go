@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+type Progress struct {
+	Rows         int     `json:"rows"`
+	BytesRead    int64   `json:"bytes_read"`
+	BytesWritten int     `json:"bytes_written"`
+	TotalBytes   int64   `json:"total_bytes,omitempty"`
+	ETASeconds   float64 `json:"eta_seconds"`
+	Done         bool    `json:"done"`
+}
+
+// ProgressReporter は進捗を1行1オブジェクトのJSONで書き出します。
+// SQLの出力先とは別のストリーム(標準エラー出力)に書くことを前提としています。
+type ProgressReporter struct {
+	out        io.Writer
+	encoder    *json.Encoder
+	interval   time.Duration
+	totalBytes int64
+	readBytes  atomic.Int64 // -parallel-within-file では読み込み側と書き出し側の goroutine から触る
+	start      time.Time
+	last       time.Time
+}
+
+func NewProgressReporter(out io.Writer, interval time.Duration, totalBytes int64) *ProgressReporter {
+	now := time.Now()
+	return &ProgressReporter{
+		out:        out,
+		encoder:    json.NewEncoder(out),
+		interval:   interval,
+		totalBytes: totalBytes,
+		start:      now,
+		last:       now,
+	}
+}
+
+// Reader は読み込んだバイト数を数えるように reader を包みます。
+func (p *ProgressReporter) Reader(reader io.Reader) io.Reader {
+	return &progressReader{reader: reader, progress: p}
+}
+
+func (p *ProgressReporter) Update(rows, bytesWritten int) {
+	now := time.Now()
+	if now.Sub(p.last) < p.interval {
+		return
+	}
+	p.last = now
+	p.emit(rows, bytesWritten, false)
+}
+
+func (p *ProgressReporter) Finish(rows, bytesWritten int) {
+	p.emit(rows, bytesWritten, true)
+}
+
+func (p *ProgressReporter) emit(rows, bytesWritten int, done bool) {
+	readBytes := p.readBytes.Load()
+	progress := Progress{
+		Rows:         rows,
+		BytesRead:    readBytes,
+		BytesWritten: bytesWritten,
+		TotalBytes:   p.totalBytes,
+		Done:         done,
+	}
+	if !done && p.totalBytes > 0 && readBytes > 0 {
+		elapsed := time.Since(p.start).Seconds()
+		remaining := float64(p.totalBytes-readBytes) / float64(readBytes)
+		progress.ETASeconds = elapsed * remaining
+	}
+	// 進捗の書き出しに失敗しても変換自体は止めない
+	_ = p.encoder.Encode(progress)
+}
+
+type progressReader struct {
+	reader   io.Reader
+	progress *ProgressReporter
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.reader.Read(b)
+	r.progress.readBytes.Add(int64(n))
+	return n, err
+}
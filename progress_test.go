@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestProgressJSON(t *testing.T) {
+	input := "id,name\n1,a\n2,b\n2,b\n3,c\n"
+	options := testOptions(t, "-dedupe-key", "id")
+	var progressOut bytes.Buffer
+	options.Progress = NewProgressReporter(&progressOut, 0, int64(len(input)))
+
+	schema := testSchema(t, "id,int,id,INT\nname,nvarchar,name,VARCHAR(10)\n", options)
+	reader := csv.NewReader(options.Progress.Reader(strings.NewReader(input)))
+	headers, err := ParseHeaders(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sql bytes.Buffer
+	report, err := WriteSQL(&sql, "t", schema, MapHeadersToSchema(headers, schema), reader, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []Progress
+	scanner := bufio.NewScanner(&progressOut)
+	for scanner.Scan() {
+		var progress Progress
+		if err := json.Unmarshal(scanner.Bytes(), &progress); err != nil {
+			t.Fatalf("progress line %q is not JSON: %s", scanner.Text(), err)
+		}
+		lines = append(lines, progress)
+	}
+	if len(lines) < 2 {
+		t.Fatalf("got %d progress lines, want updates and a final line", len(lines))
+	}
+
+	for i := 1; i < len(lines); i++ {
+		if lines[i].Rows < lines[i-1].Rows {
+			t.Errorf("rows went backwards: %d after %d", lines[i].Rows, lines[i-1].Rows)
+		}
+	}
+	for _, progress := range lines[:len(lines)-1] {
+		if progress.Done {
+			t.Errorf("intermediate progress line %+v is marked done", progress)
+		}
+	}
+
+	last := lines[len(lines)-1]
+	want := Progress{Rows: report.Rows, BytesRead: int64(len(input)), BytesWritten: sql.Len(), TotalBytes: int64(len(input)), Done: true}
+	if last != want {
+		t.Errorf("final progress = %+v, want %+v", last, want)
+	}
+	if report.Rows != 3 {
+		t.Errorf("report.Rows = %d, want 3 after dropping the duplicate", report.Rows)
+	}
+	if strings.Contains(sql.String(), "bytes_read") {
+		t.Errorf("progress leaked into the SQL output:\n%s", sql.String())
+	}
+}
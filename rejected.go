@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io"
+)
+
+const rejectedFileExtension = ".rejected.csv"
+
+// RejectedRows は読み込めずに飛ばした行を、ヘッダー行とともに元の表記のまま書き出します。
+// 書き出したファイルは修正してそのまま入力ファイルとして再実行できます。
+//
+// csv.Reader は先読みするので、Reader で入力を記録しておき、InputOffset で
+// 1レコード分ずつ切り出します。
+type RejectedRows struct {
+	out    io.Writer
+	buf    []byte
+	offset int64 // buf[0] の入力上の位置
+	header []byte
+	last   []byte
+}
+
+func NewRejectedRows(out io.Writer) *RejectedRows {
+	return &RejectedRows{out: out}
+}
+
+// Reader は csv.Reader に渡す前の入力を包み、読んだ内容を記録します。
+func (r *RejectedRows) Reader(reader io.Reader) io.Reader {
+	return &rejectedRowsReader{reader: reader, rejected: r}
+}
+
+// next は前回から inputOffset までの、直前に読んだ1レコード分の元の表記を切り出します。
+func (r *RejectedRows) next(inputOffset int64) {
+	n := int(inputOffset - r.offset)
+	if n > len(r.buf) {
+		n = len(r.buf)
+	}
+	r.last = append(r.last[:0], r.buf[:n]...)
+	r.buf = r.buf[n:]
+	r.offset += int64(n)
+}
+
+// setHeader は直前に読んだレコードをヘッダー行として覚えます。
+func (r *RejectedRows) setHeader(inputOffset int64) {
+	r.next(inputOffset)
+	r.header = append([]byte(nil), r.last...)
+}
+
+// reject は直前に読んだレコードを書き出します。最初の1行の前にヘッダー行を書きます。
+func (r *RejectedRows) reject(count int) error {
+	if count == 0 {
+		if _, err := r.out.Write(r.header); err != nil {
+			return err
+		}
+	}
+	_, err := r.out.Write(r.last)
+	return err
+}
+
+type rejectedRowsReader struct {
+	reader   io.Reader
+	rejected *RejectedRows
+}
+
+func (r *rejectedRowsReader) Read(b []byte) (int, error) {
+	n, err := r.reader.Read(b)
+	r.rejected.buf = append(r.rejected.buf, b[:n]...)
+	return n, err
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		rows     int
+		rejected string
+	}{
+		{
+			name:     "wrong field count",
+			input:    "id,name\n1,a\n2,b,extra\n3,c\n4\n",
+			rows:     2,
+			rejected: "id,name\n2,b,extra\n4\n",
+		},
+		{
+			// 元の表記のまま書くので、クォートや改行を含む行も修正して入力に戻せる
+			name:     "quoted field",
+			input:    "id,name\r\n1,\"multi\nline\",x\r\n2,b\r\n",
+			rows:     1,
+			rejected: "id,name\r\n1,\"multi\nline\",x\r\n",
+		},
+		{
+			name:  "no rejected rows",
+			input: "id,name\n1,a\n",
+			rows:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{
+				"input.csv":  tt.input,
+				"schema.csv": "id,int,id,INT\nname,nvarchar,name,VARCHAR(20)\n",
+			}
+			if err := runConvert(t, files, "-write-rejected", "t", "input.csv", "schema.csv"); err != nil {
+				t.Fatal(err)
+			}
+			if n := strings.Count(readOutput(t, "t.SQL"), "('"); n != tt.rows {
+				t.Errorf("%d rows converted, want %d", n, tt.rows)
+			}
+			if tt.rejected == "" {
+				if _, err := os.Stat("t" + rejectedFileExtension); !os.IsNotExist(err) {
+					t.Errorf("rejected file was created: %v", err)
+				}
+				return
+			}
+			if got := readOutput(t, "t"+rejectedFileExtension); got != tt.rejected {
+				t.Errorf("rejected rows %q, want %q", got, tt.rejected)
+			}
+		})
+	}
+}
+
+func TestWriteRejectedWithoutSQL(t *testing.T) {
+	// SQL を書かないモードでも、読み捨てた行は書き出す
+	tests := []struct {
+		name  string
+		flags []string
+	}{
+		{"stats", []string{"-stats"}},
+		{"preview diff", []string{"-preview-diff", "current.csv"}},
+		{"foreign keys", []string{"-validate-foreign-keys", "id=parents.csv"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{
+				"input.csv":   "id,name\n1,a\n2\n3,c\n",
+				"schema.csv":  "id,int,id,INT\nname,nvarchar,name,VARCHAR(20)\n",
+				"current.csv": "id,name\n1,a\n",
+				"parents.csv": "id\n1\n3\n",
+			}
+			args := append(append([]string{"-write-rejected"}, tt.flags...), "t", "input.csv", "schema.csv")
+			if err := runConvert(t, files, args...); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := readOutput(t, "t"+rejectedFileExtension), "id,name\n2\n"; got != want {
+				t.Errorf("rejected rows %q, want %q", got, want)
+			}
+		})
+	}
+}
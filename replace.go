@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// nullReplacement は -replace-global の置換先で NULL を表す表記です。LOAD DATA と同じく \N と書きます。
+const nullReplacement = `\N`
+
+// globalReplacement は -replace-global で入力の値を置き換える先です。
+type globalReplacement struct {
+	value  string
+	isNull bool
+}
+
+// parseGlobalReplacements は -replace-global の from=to を、入力の値から置換先への対応にします。
+// スキーマの変換と違って全カラムに適用するので、(null) のようにエクスポート全体で使われる
+// 目印の文字列を NULL や空に戻すのに使います。
+func parseGlobalReplacements(specs []string) (map[string]globalReplacement, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	replacements := make(map[string]globalReplacement, len(specs))
+	for _, spec := range specs {
+		from, to, ok := strings.Cut(spec, "=")
+		if !ok || from == "" {
+			return nil, fmt.Errorf("invalid -replace-global %q: expected from=to", spec)
+		}
+		if _, exists := replacements[from]; exists {
+			return nil, fmt.Errorf("invalid -replace-global %q: %q is already replaced", spec, from)
+		}
+		replacements[from] = globalReplacement{value: to, isNull: to == nullReplacement}
+	}
+	return replacements, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReplaceGlobal(t *testing.T) {
+	const schema = "id,int,id,INT\nname,nvarchar,name,VARCHAR(20),trim\n=name + '!',nvarchar,shout,VARCHAR(20)\n"
+	const input = "id,name\n(null),(null)\n1, (null) \n2,N/A\n3,(null) value\n"
+	sql, _, err := generate(t, schema, input, testOptions(t, "-replace-global", `(null)=\N`, "-replace-global", "N/A="))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"(NULL, NULL, '(null)!')",      // 式のカラムには適用しない
+		"('1', '(null)', ' (null) !')", // 値全体が一致する場合だけ置き換え、trim より前に比べる
+		"('2', '', 'N/A!')",
+		"('3', '(null) value', '(null) value!')",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("output does not contain %s:\n%s", want, sql)
+		}
+	}
+}
+
+func TestParseGlobalReplacements(t *testing.T) {
+	got, err := parseGlobalReplacements([]string{`NULL=\N`, "-=", "a=b=c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]globalReplacement{"NULL": {isNull: true, value: `\N`}, "-": {}, "a": {value: "b=c"}}
+	for from, replacement := range want {
+		if got[from] != replacement {
+			t.Errorf("%q = %+v, want %+v", from, got[from], replacement)
+		}
+	}
+	for _, specs := range [][]string{{"novalue"}, {"=x"}, {"a=1", "a=2"}} {
+		if _, err := parseGlobalReplacements(specs); err == nil {
+			t.Errorf("parseGlobalReplacements(%q) succeeded, want an error", specs)
+		}
+	}
+}
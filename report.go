@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Warning は変換は続けられるものの利用者に知らせるべき事象です。
+type Warning struct {
+	Row     int // データ行の番号(1始まり、ヘッダーを除く)。行に関係しない警告では 0
+	Column  string
+	Message string
+}
+
+func (w Warning) String() string {
+	if w.Row == 0 {
+		return fmt.Sprintf("column %s: %s", w.Column, w.Message)
+	}
+	return fmt.Sprintf("row %d, column %s: %s", w.Row, w.Column, w.Message)
+}
+
+// Report は GenerateSQL の実行結果の集計です。
+type Report struct {
+	Rows          int
+	DuplicateRows int
+	RejectedRows  int
+	Warnings      []Warning
+
+	// ColumnValues は重複を除いて出力した行の値のうち、NULL、DEFAULT と空文字列を除いた値の数を
+	// カラムごとに数えたものです
+	ColumnValues map[string]int
+
+	// UnconvertedTypes は convertData に変換がなく値をそのまま通した型の組み合わせと、その値の数です
+	UnconvertedTypes map[TypeMapping]int
+
+	// DateFormats は -date-format のどの書式で何件の値を読んだかを、カラムごとに数えたものです
+	DateFormats map[DateFormatUsage]int
+
+	// TransformSamples は -transform-audit で記録した変換の前後の値で、入力の順に並びます
+	TransformSamples []TransformSample
+	transformCounts  map[string]int
+}
+
+// TransformSample は変換を指定したカラムの1つの値の、変換前と変換後です。
+type TransformSample struct {
+	Row    int
+	Column string
+	Before string
+	After  string
+}
+
+func (s TransformSample) String() string {
+	return fmt.Sprintf("row %d, column %s: %q -> %q", s.Row, s.Column, s.Before, s.After)
+}
+
+type DateFormatUsage struct {
+	Column string
+	Layout string
+}
+
+type TypeMapping struct {
+	From string
+	To   string
+}
+
+func (m TypeMapping) String() string {
+	return fmt.Sprintf("%s -> %s", m.From, m.To)
+}
+
+func (r *Report) Warn(row int, column, format string, args ...any) {
+	r.Warnings = append(r.Warnings, Warning{
+		Row:     row,
+		Column:  column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *Report) addUnconverted(srcType, destType string) {
+	if r.UnconvertedTypes == nil {
+		r.UnconvertedTypes = make(map[TypeMapping]int)
+	}
+	r.UnconvertedTypes[TypeMapping{From: sourceTypeName(srcType), To: destType}]++
+}
+
+// SortedUnconvertedTypes は UnconvertedTypes を表示用に並べ替えて返します。
+func (r *Report) SortedUnconvertedTypes() []TypeMapping {
+	mappings := make([]TypeMapping, 0, len(r.UnconvertedTypes))
+	for mapping := range r.UnconvertedTypes {
+		mappings = append(mappings, mapping)
+	}
+	sort.Slice(mappings, func(i, j int) bool {
+		return mappings[i].String() < mappings[j].String()
+	})
+	return mappings
+}
+
+func (r *Report) addColumnValues(schema []Schema, values []Value) {
+	if r.ColumnValues == nil {
+		r.ColumnValues = make(map[string]int)
+	}
+	for j, value := range values {
+		if value != nullValue && value != defaultValue && value.Text != "" {
+			r.ColumnValues[schema[j].ColumnTo]++
+		}
+	}
+}
+
+func (r *Report) addDateFormat(column, layout string) {
+	if r.DateFormats == nil {
+		r.DateFormats = make(map[DateFormatUsage]int)
+	}
+	r.DateFormats[DateFormatUsage{Column: column, Layout: layout}]++
+}
+
+// addTransformSample はカラムごとに先頭の limit 件まで変換の前後の値を記録します。
+func (r *Report) addTransformSample(sample TransformSample, limit int) {
+	if r.transformCounts == nil {
+		r.transformCounts = make(map[string]int)
+	}
+	if r.transformCounts[sample.Column] >= limit {
+		return
+	}
+	r.transformCounts[sample.Column]++
+	r.TransformSamples = append(r.TransformSamples, sample)
+}
+
+// SortedDateFormats は DateFormats をカラム名、書式の順に並べ替えて返します。
+func (r *Report) SortedDateFormats() []DateFormatUsage {
+	usages := make([]DateFormatUsage, 0, len(r.DateFormats))
+	for usage := range r.DateFormats {
+		usages = append(usages, usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Column != usages[j].Column {
+			return usages[i].Column < usages[j].Column
+		}
+		return usages[i].Layout < usages[j].Layout
+	})
+	return usages
+}
+
+// merge は行ごとに集計した Report を r に加えます。-parallel-within-file で
+// 並行して変換した行の警告を、入力の順に r へまとめるのに使います。
+// transformAudit は -transform-audit の件数で、逐次の場合と同じ先頭の値だけを残します。
+func (r *Report) merge(other *Report, transformAudit int) {
+	r.Warnings = append(r.Warnings, other.Warnings...)
+	for _, sample := range other.TransformSamples {
+		r.addTransformSample(sample, transformAudit)
+	}
+	for mapping, n := range other.UnconvertedTypes {
+		if r.UnconvertedTypes == nil {
+			r.UnconvertedTypes = make(map[TypeMapping]int)
+		}
+		r.UnconvertedTypes[mapping] += n
+	}
+	for usage, n := range other.DateFormats {
+		if r.DateFormats == nil {
+			r.DateFormats = make(map[DateFormatUsage]int)
+		}
+		r.DateFormats[usage] += n
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestReportUnconvertedTypes(t *testing.T) {
+	schema := `id,int,id,INT
+doc,int,doc,JSON
+geo,geography,geo,GEOMETRY
+name,nvarchar,name,VARCHAR(10)
+amount,decimal(18,2),amount,"DECIMAL(18,2)"
+`
+	_, report, err := generate(t, schema, "id,doc,geo,name,amount\n1,2,x,a,1.5\n2,3,y,b,2\n", testOptions(t, "-report-unconverted-types"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[TypeMapping]int{
+		{From: "int", To: "JSON"}:           2,
+		{From: "geography", To: "GEOMETRY"}: 2,
+	}
+	if !reflect.DeepEqual(report.UnconvertedTypes, want) {
+		t.Errorf("UnconvertedTypes = %v, want %v", report.UnconvertedTypes, want)
+	}
+	if got, want := report.SortedUnconvertedTypes(), []TypeMapping{{From: "geography", To: "GEOMETRY"}, {From: "int", To: "JSON"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SortedUnconvertedTypes = %v, want %v", got, want)
+	}
+}
+
+func TestTransformAudit(t *testing.T) {
+	const schema = "code,nvarchar,code,VARCHAR(10),trim:#\nphone,nvarchar,phone,VARCHAR(20),regexp:[^0-9]/\nname,nvarchar,name,VARCHAR(10)\n"
+	const input = "code,phone,name\n#A1#,(03) 1234,a\nB2,03-5678,b\n#C3,090 1,c\n"
+	tests := []struct {
+		limit int
+		want  []TransformSample
+	}{
+		{0, nil},
+		{1, []TransformSample{
+			{Row: 1, Column: "code", Before: "#A1#", After: "A1"},
+			{Row: 1, Column: "phone", Before: "(03) 1234", After: "031234"},
+		}},
+		// 変換を指定していない name は記録しない。変わらなかった値も記録する
+		{2, []TransformSample{
+			{Row: 1, Column: "code", Before: "#A1#", After: "A1"},
+			{Row: 1, Column: "phone", Before: "(03) 1234", After: "031234"},
+			{Row: 2, Column: "code", Before: "B2", After: "B2"},
+			{Row: 2, Column: "phone", Before: "03-5678", After: "035678"},
+		}},
+		{10, []TransformSample{
+			{Row: 1, Column: "code", Before: "#A1#", After: "A1"},
+			{Row: 1, Column: "phone", Before: "(03) 1234", After: "031234"},
+			{Row: 2, Column: "code", Before: "B2", After: "B2"},
+			{Row: 2, Column: "phone", Before: "03-5678", After: "035678"},
+			{Row: 3, Column: "code", Before: "#C3", After: "C3"},
+			{Row: 3, Column: "phone", Before: "090 1", After: "0901"},
+		}},
+	}
+	for _, tt := range tests {
+		_, report, err := generate(t, schema, input, testOptions(t, "-transform-audit", strconv.Itoa(tt.limit)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(report.TransformSamples, tt.want) {
+			t.Errorf("-transform-audit %d: samples %v, want %v", tt.limit, report.TransformSamples, tt.want)
+		}
+	}
+	if _, err := ParseArgs([]string{"convert", "-transform-audit", "-1", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted -transform-audit -1")
+	}
+}
+
+func TestTransformSampleMerge(t *testing.T) {
+	// 並行して変換した結果をまとめても、カラムごとの先頭 limit 件だけが残る
+	var first, second, report Report
+	first.addTransformSample(TransformSample{Row: 1, Column: "a", Before: "1", After: "x"}, 2)
+	first.addTransformSample(TransformSample{Row: 1, Column: "b", Before: "1", After: "y"}, 2)
+	second.addTransformSample(TransformSample{Row: 2, Column: "a", Before: "2", After: "x"}, 2)
+	second.addTransformSample(TransformSample{Row: 3, Column: "a", Before: "3", After: "x"}, 2)
+	report.merge(&first, 2)
+	report.merge(&second, 2)
+
+	var got []int
+	for _, sample := range report.TransformSamples {
+		if sample.Column == "a" {
+			got = append(got, sample.Row)
+		}
+	}
+	if !reflect.DeepEqual(got, []int{1, 2}) || len(report.TransformSamples) != 3 {
+		t.Errorf("merged samples %v", report.TransformSamples)
+	}
+	if got, want := report.TransformSamples[0].String(), `row 1, column a: "1" -> "x"`; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
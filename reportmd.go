@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const ReportMarkdown = "markdown"
+
+// reportFileExtension は -report markdown で出力ファイルの隣に書く変換結果の表の拡張子です。
+const reportFileExtension = ".report.md"
+
+func validateReportFormat(format string) error {
+	switch format {
+	case "", ReportMarkdown:
+		return nil
+	}
+	return fmt.Errorf("invalid -report %q: must be %s", format, ReportMarkdown)
+}
+
+// WriteMarkdownReport は移行の記録に貼れるよう、カラムごとの型の対応と変換した行数、警告の件数を
+// Markdown の表にして書き出します。カラムの行数は値が NULL や空でなかった行の数です。
+// 変換のない型の組み合わせや -date-format で読んだ書式は備考に書きます。
+func WriteMarkdownReport(writer io.Writer, tableName string, schema []Schema, report *Report) error {
+	warnings := make(map[string]int)
+	for _, warning := range report.Warnings {
+		warnings[warning.Column]++
+	}
+	dateFormats := make(map[string][]string)
+	for _, usage := range report.SortedDateFormats() {
+		dateFormats[usage.Column] = append(dateFormats[usage.Column], fmt.Sprintf("%s (%d values)", usage.Layout, report.DateFormats[usage]))
+	}
+
+	var md strings.Builder
+	fmt.Fprintf(&md, "# %s\n\n", markdownCell(tableName))
+	fmt.Fprintf(&md, "%d rows converted", report.Rows)
+	if report.RejectedRows > 0 {
+		fmt.Fprintf(&md, ", %d rows rejected", report.RejectedRows)
+	}
+	if report.DuplicateRows > 0 {
+		fmt.Fprintf(&md, ", %d duplicate rows dropped", report.DuplicateRows)
+	}
+	md.WriteString(".\n\n")
+
+	md.WriteString("| Column | Source type | Destination type | Rows | Warnings | Notes |\n")
+	md.WriteString("| --- | --- | --- | ---: | ---: | --- |\n")
+	for _, column := range schema {
+		var notes []string
+		destType := ParseDataType(column.DataTypeTo)
+		mapping := TypeMapping{From: sourceTypeName(column.DataTypeFrom), To: destType.Name}
+		if report.UnconvertedTypes[mapping] > 0 {
+			notes = append(notes, "passed through without conversion")
+		}
+		if formats := dateFormats[column.ColumnTo]; len(formats) > 0 {
+			notes = append(notes, "date formats: "+strings.Join(formats, ", "))
+		}
+		fmt.Fprintf(&md, "| %s | %s | %s | %d | %d | %s |\n",
+			markdownCell(column.ColumnTo), markdownCell(column.DataTypeFrom), markdownCell(column.DataTypeTo),
+			report.ColumnValues[column.ColumnTo], warnings[column.ColumnTo], markdownCell(strings.Join(notes, "; ")))
+	}
+
+	_, err := io.WriteString(writer, md.String())
+	return err
+}
+
+var markdownCellEscaper = strings.NewReplacer("|", `\|`, "\n", " ", "\r", "")
+
+func markdownCell(text string) string {
+	return markdownCellEscaper.Replace(text)
+}
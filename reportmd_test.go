@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteMarkdownReport(t *testing.T) {
+	const schema = "id,int,id,INT\nflag,tinyint,flag,TINYINT\nborn,nvarchar(10),born,DATE\nmemo,xml,memo,TEXT\n"
+	const input = "id,flag,born,memo\n1,200,2023-01-02,<a/>\n2,1,01/03/2023,a|b\n3,1,bad\n2,1,,\n4,,,\n"
+	options := testOptions(t, "-date-format", "2006-01-02", "-date-format", "01/02/2006", "-dedupe-key", "id")
+	_, report, err := generate(t, schema, input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 行数は重複を除いた後で、値が空の行は数えない
+	if want := map[string]int{"id": 3, "flag": 2, "born": 2, "memo": 2}; !reflect.DeepEqual(report.ColumnValues, want) {
+		t.Errorf("ColumnValues = %v, want %v", report.ColumnValues, want)
+	}
+
+	var output bytes.Buffer
+	if err := WriteMarkdownReport(&output, "orders|2023", testSchema(t, schema, options), report); err != nil {
+		t.Fatal(err)
+	}
+	want := `# orders\|2023
+
+3 rows converted, 1 rows rejected, 1 duplicate rows dropped.
+
+| Column | Source type | Destination type | Rows | Warnings | Notes |
+| --- | --- | --- | ---: | ---: | --- |
+| id | int | INT | 3 | 0 |  |
+| flag | tinyint | TINYINT | 2 | 1 |  |
+| born | nvarchar(10) | DATE | 2 | 0 | date formats: 01/02/2006 (1 values), 2006-01-02 (1 values) |
+| memo | xml | TEXT | 2 | 0 | passed through without conversion |
+`
+	if got := output.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMarkdownReportFile(t *testing.T) {
+	files := map[string]string{"input.csv": "id\n1\n", "schema.csv": "id,int,id,INT\n"}
+	if err := runConvert(t, files, "-report", "markdown", "t", "input.csv", "schema.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readOutput(t, "t"+reportFileExtension); !strings.HasPrefix(got, "# t\n\n1 rows converted.\n") {
+		t.Errorf("report:\n%s", got)
+	}
+	if _, err := ParseArgs([]string{"convert", "-report", "html", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted -report html")
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// schemaEnvPrefix で始まるスキーマファイル名は、ファイルの代わりに環境変数から読みます(env:SQLCONV_SCHEMA など)。
+const schemaEnvPrefix = "env:"
+
+// schemaJSONColumn は環境変数に JSON で書いたスキーマの1カラム分です。
+//
+//	[{"columnFrom": "id", "dataTypeFrom": "int", "columnTo": "id", "dataTypeTo": "BIGINT", "transforms": ["trim"]}]
+type schemaJSONColumn struct {
+	ColumnFrom   string   `json:"columnFrom"`
+	DataTypeFrom string   `json:"dataTypeFrom"`
+	ColumnTo     string   `json:"columnTo"`
+	DataTypeTo   string   `json:"dataTypeTo"`
+	Transforms   []string `json:"transforms"`
+}
+
+// readSchemaEnv は環境変数からスキーマを読み、CSV と同じ行の並びにして返します。
+// 内容が [ で始まる場合は JSON、それ以外は CSV として扱います。
+func readSchemaEnv(name string) ([][]string, error) {
+	content, ok := os.LookupEnv(name)
+	if !ok || strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("schema environment variable %s is not set", name)
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(content), "[") {
+		var columns []schemaJSONColumn
+		if err := json.Unmarshal([]byte(content), &columns); err != nil {
+			return nil, fmt.Errorf("failed to read schema from %s: %s", name, err)
+		}
+		records := make([][]string, 0, len(columns))
+		for _, column := range columns {
+			record := []string{column.ColumnFrom, column.DataTypeFrom, column.ColumnTo, column.DataTypeTo}
+			records = append(records, append(record, column.Transforms...))
+		}
+		return records, nil
+	}
+
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema from %s: %s", name, err)
+	}
+	return records, nil
+}
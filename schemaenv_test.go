@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadSchemaEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"csv", "UserID,int,id,BIGINT\nUserName,nvarchar,name,VARCHAR(20),trim\n"},
+		{"json", `[
+			{"columnFrom": "UserID", "dataTypeFrom": "int", "columnTo": "id", "dataTypeTo": "BIGINT"},
+			{"columnFrom": "UserName", "dataTypeFrom": "nvarchar", "columnTo": "name", "dataTypeTo": "VARCHAR(20)", "transforms": ["trim"]}
+		]`},
+		{"json with leading space", "\n  [{\"columnFrom\":\"UserID\",\"dataTypeFrom\":\"int\",\"columnTo\":\"id\",\"dataTypeTo\":\"BIGINT\"}," +
+			"{\"columnFrom\":\"UserName\",\"dataTypeFrom\":\"nvarchar\",\"columnTo\":\"name\",\"dataTypeTo\":\"VARCHAR(20)\",\"transforms\":[\"trim\"]}]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SQLCONV_TEST_SCHEMA", tt.content)
+			schema, err := ReadSchema("env:SQLCONV_TEST_SCHEMA", Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(schema) != 2 {
+				t.Fatalf("schema = %+v, want 2 columns", schema)
+			}
+			if schema[0].ColumnFrom != "UserID" || schema[0].DataTypeFrom != "int" || schema[0].ColumnTo != "id" || schema[0].DataTypeTo != "BIGINT" {
+				t.Errorf("column 1 = %+v", schema[0])
+			}
+			if schema[1].ColumnTo != "name" || !schema[1].HasTransform("trim") {
+				t.Errorf("column 2 = %+v, want name with trim", schema[1])
+			}
+		})
+	}
+}
+
+func TestReadSchemaEnvErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		set     bool
+		want    string
+	}{
+		{"", false, "schema environment variable SQLCONV_TEST_SCHEMA is not set"},
+		{"  \n", true, "schema environment variable SQLCONV_TEST_SCHEMA is not set"},
+		{`[{"columnFrom": "id",}]`, true, "failed to read schema from SQLCONV_TEST_SCHEMA"},
+		{"id,int,\"id\n", true, "failed to read schema from SQLCONV_TEST_SCHEMA"},
+		{"id,int,id\n", true, "schema line 1: expected at least 4 fields, got 3"},
+	}
+	for _, tt := range tests {
+		if tt.set {
+			t.Setenv("SQLCONV_TEST_SCHEMA", tt.content)
+		}
+		_, err := ReadSchema("env:SQLCONV_TEST_SCHEMA", Options{})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: error %v, want %q", tt.content, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sqlServerTypes はスキーマの DataTypeFrom として受け付ける SQL Server の型です。
+var sqlServerTypes = map[string]bool{
+	"bit": true, "tinyint": true, "smallint": true, "int": true, "bigint": true,
+	"decimal": true, "numeric": true, "money": true, "smallmoney": true, "float": true, "real": true,
+	"char": true, "varchar": true, "nchar": true, "nvarchar": true, "text": true, "ntext": true,
+	"binary": true, "varbinary": true, "image": true,
+	"date": true, "time": true, "datetime": true, "datetime2": true, "smalldatetime": true, "datetimeoffset": true,
+	"uniqueidentifier": true, "xml": true, "sql_variant": true, "timestamp": true, "rowversion": true,
+}
+
+// mysqlTypes はスキーマの DataTypeTo として受け付ける MySQL の型です。
+var mysqlTypes = map[string]bool{
+	"BIT": true, "BOOL": true, "BOOLEAN": true,
+	"TINYINT": true, "SMALLINT": true, "MEDIUMINT": true, "INT": true, "INTEGER": true, "BIGINT": true,
+	"DECIMAL": true, "NUMERIC": true, "DEC": true, "FIXED": true, "FLOAT": true, "DOUBLE": true, "REAL": true,
+	"CHAR": true, "VARCHAR": true, "NCHAR": true, "NVARCHAR": true,
+	"TINYTEXT": true, "TEXT": true, "MEDIUMTEXT": true, "LONGTEXT": true,
+	"BINARY": true, "VARBINARY": true, "TINYBLOB": true, "BLOB": true, "MEDIUMBLOB": true, "LONGBLOB": true,
+	"DATE": true, "TIME": true, "DATETIME": true, "TIMESTAMP": true, "YEAR": true,
+	"ENUM": true, "SET": true, "JSON": true,
+}
+
+// ValidateSchema はスキーマだけで判断できる矛盾を探します。
+// 列数や変換の書式は ReadSchema が読み込み時に確認済みです。
+func ValidateSchema(schema []Schema) []error {
+	var problems []error
+	destinations := make(map[string]int)
+	for i, column := range schema {
+		line := i + 1
+		if column.ColumnTo == "" {
+			problems = append(problems, fmt.Errorf("schema line %d: destination column is empty", line))
+		} else if first, ok := destinations[strings.ToLower(column.ColumnTo)]; ok {
+			// MySQL のカラム名は大文字小文字を区別しない
+			problems = append(problems, fmt.Errorf("schema line %d: destination column %s is already mapped on line %d", line, column.ColumnTo, first))
+		} else {
+			destinations[strings.ToLower(column.ColumnTo)] = line
+		}
+
+		if !sqlServerTypes[sourceTypeName(column.DataTypeFrom)] {
+			problems = append(problems, fmt.Errorf("schema line %d: unknown SQL Server type %q", line, column.DataTypeFrom))
+		}
+		if destType := ParseDataType(column.DataTypeTo); !mysqlTypes[destType.Name] {
+			problems = append(problems, fmt.Errorf("schema line %d: unknown MySQL type %q", line, column.DataTypeTo))
+		}
+	}
+	return problems
+}
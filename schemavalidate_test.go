@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   []string
+	}{
+		{
+			name:   "valid",
+			schema: "id,int,id,INT\nprice,decimal(18,2),price,DECIMAL(18,2)\nname,nvarchar(50),name,VARCHAR(50)\ncreated,datetime2,created,DATETIME(6)\n",
+		},
+		{
+			name:   "unknown types",
+			schema: "id,integer,id,INT\nname,nvarchar,name,STRING(10)\n",
+			want: []string{
+				`schema line 1: unknown SQL Server type "integer"`,
+				`schema line 2: unknown MySQL type "STRING(10)"`,
+			},
+		},
+		{
+			name:   "duplicate destination",
+			schema: "id,int,id,INT\nID2,int,ID,INT\n",
+			want:   []string{"schema line 2: destination column ID is already mapped on line 1"},
+		},
+		{
+			name:   "empty destination",
+			schema: "id,int,,INT\n",
+			want:   []string{"schema line 1: destination column is empty"},
+		},
+	}
+	for _, tt := range tests {
+		problems := ValidateSchema(testSchema(t, tt.schema, Options{}))
+		if len(problems) != len(tt.want) {
+			t.Errorf("%s: problems %v, want %q", tt.name, problems, tt.want)
+			continue
+		}
+		for i, problem := range problems {
+			if problem.Error() != tt.want[i] {
+				t.Errorf("%s: problem %d = %q, want %q", tt.name, i, problem, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestSchemaValidateArgs(t *testing.T) {
+	args, err := ParseArgs([]string{"convert", "-schema-validate", "schema.csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !args.SchemaValidate || args.SchemaFileName != "schema.csv" {
+		t.Errorf("ParseArgs = %+v", args)
+	}
+	if _, err := ParseArgs([]string{"convert", "-schema-validate", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted -schema-validate with three arguments")
+	}
+}
+
+func TestParseSchemaMalformed(t *testing.T) {
+	for _, records := range [][][]string{
+		{{"id", "int", "id"}},
+		{{"id", "int", "id", "INT", "no-such-transform"}},
+	} {
+		if _, err := parseSchema(records, Options{}); err == nil {
+			t.Errorf("parseSchema(%q) succeeded, want an error", records)
+		}
+	}
+}
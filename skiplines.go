@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// skipLines は入力の先頭の n 行を読み捨てます。SQL Server の出力に付く説明の行などは CSV として
+// 読めるとは限らないので、レコードではなく改行で数えます。ヘッダーの前に BOM があれば、
+// ReadInputFile が取り除くファイル先頭の BOM と同じように取り除きます。
+func skipLines(reader io.Reader, n int) (io.Reader, error) {
+	if n == 0 {
+		return reader, nil
+	}
+
+	buffered := bufio.NewReader(reader)
+	for i := 0; i < n; i++ {
+		for {
+			_, err := buffered.ReadSlice('\n')
+			if err == nil {
+				break
+			}
+			if errors.Is(err, bufio.ErrBufferFull) {
+				continue // バッファより長い行は続きを読み捨てる
+			}
+			if err == io.EOF {
+				return buffered, nil // ヘッダーがないので ParseHeaders が ErrEmptyInput を返す
+			}
+			return nil, fmt.Errorf("failed to skip line %d of input file: %s", i+1, err)
+		}
+	}
+
+	if prefix, _ := buffered.Peek(3); len(removeBOM(prefix)) < len(prefix) {
+		buffered.Discard(len(prefix))
+	}
+	return buffered, nil
+}
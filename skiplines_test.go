@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSkipLines(t *testing.T) {
+	const bom = "\ufeff"
+	tests := []struct {
+		input string
+		n     int
+		want  string
+	}{
+		{"id\n1\n", 0, "id\n1\n"},
+		{"note\nid\n1\n", 1, "id\n1\n"},
+		{"note 1\r\nnote \"2\r\nid\n1\n", 2, "id\n1\n"}, // CSV として読めない行も読み捨てる
+		{strings.Repeat("x", 10000) + "\nid\n", 1, "id\n"},
+		{"note\n" + bom + "id\n1\n", 1, "id\n1\n"},
+		{"note\n" + bom + "id," + bom + "x\n", 1, "id," + bom + "x\n"}, // 取り除くのはヘッダー先頭の BOM だけ
+		{bom + "id\n1\n", 0, bom + "id\n1\n"},                          // ファイル先頭の BOM は ReadInputFile が取り除く
+		{"note\n", 1, ""},
+		{"note", 3, ""},
+	}
+	for _, tt := range tests {
+		reader, err := skipLines(strings.NewReader(tt.input), tt.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("skipLines(%q, %d) = %q, want %q", tt.input, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSkipLinesBOMHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"BOM at file start", "\ufeffExported by SSMS\n(3 rows affected)\nid,name\n1,a\n"},
+		{"BOM after skipped lines", "Exported by SSMS\n(3 rows affected)\n\ufeffid,name\n1,a\n"},
+		{"CRLF", "Exported by SSMS\r\n(3 rows affected)\r\n\ufeffid,name\r\n1,a\r\n"},
+	}
+	for _, tt := range tests {
+		files := map[string]string{
+			"input.csv":  tt.input,
+			"schema.csv": "id,int,id,INT\nname,nvarchar,name,VARCHAR(10)\n",
+		}
+		if err := runConvert(t, files, "-skip-lines", "2", "t", "input.csv", "schema.csv"); err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if output := readOutput(t, "t.SQL"); !strings.Contains(output, "(`id`, `name`)") || !strings.Contains(output, "('1', 'a')") {
+			t.Errorf("%s: got\n%s", tt.name, output)
+		}
+	}
+
+	if _, err := ParseArgs([]string{"convert", "-skip-lines", "-1", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted -skip-lines -1")
+	}
+}
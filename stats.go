@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// statsDistinctLimit を超える種類の値を持つカラムは、種類の数を数えません。
+const statsDistinctLimit = 100
+
+// ColumnStats は -stats で集計する1カラム分の統計です。
+// 最小値・最大値は数値型と日付型のカラムだけで求めます。
+type ColumnStats struct {
+	Column string
+	Empty  int
+	Min    string
+	Max    string
+
+	ordered  func(a, b string) (int, bool) // nil なら最小値・最大値は求めない
+	distinct map[string]struct{}
+}
+
+func newColumnStats(column Schema) *ColumnStats {
+	stats := &ColumnStats{Column: column.ColumnTo, distinct: make(map[string]struct{})}
+
+	destType := ParseDataType(column.DataTypeTo)
+	switch {
+	case isNumericDestType(destType):
+		stats.ordered = compareNumbers
+	case isDateDestType(destType), destType.Name == "TIME", destType.Name == "YEAR":
+		stats.ordered = compareDates
+	}
+	return stats
+}
+
+func isNumericDestType(destType DataType) bool {
+	if _, ok := integerBits(destType.Name); ok {
+		return true
+	}
+	switch destType.Name {
+	case "DECIMAL", "NUMERIC", "DEC", "FIXED", "FLOAT", "DOUBLE", "REAL":
+		return true
+	}
+	return false
+}
+
+// compareNumbers は2つの数値表記を比べます。数値として読めない値があれば false を返します。
+func compareNumbers(a, b string) (int, bool) {
+	x, ok := new(big.Rat).SetString(strings.TrimSpace(a))
+	if !ok {
+		return 0, false
+	}
+	y, ok := new(big.Rat).SetString(strings.TrimSpace(b))
+	if !ok {
+		return 0, false
+	}
+	return x.Cmp(y), true
+}
+
+// compareDates は2つの日付表記を比べます。MySQL の日付表記は文字列の順序と
+// 時刻の順序が一致するので、日付として読めることだけを確認して文字列で比べます。
+func compareDates(a, b string) (int, bool) {
+	for _, value := range []string{a, b} {
+		if !isDateValue(value) {
+			return 0, false
+		}
+	}
+	return strings.Compare(a, b), true
+}
+
+func isDateValue(value string) bool {
+	for _, layout := range append([]string{"2006-01-02", "15:04:05.999999999", "2006"}, datetimeLayouts...) {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ColumnStats) add(value Value) {
+	if value == defaultValue || value == nullValue || value.Text == "" {
+		s.Empty++
+		return
+	}
+
+	if s.distinct != nil {
+		s.distinct[value.Text] = struct{}{}
+		if len(s.distinct) > statsDistinctLimit {
+			s.distinct = nil
+		}
+	}
+
+	if s.ordered == nil {
+		return
+	}
+	if _, ok := s.ordered(value.Text, value.Text); !ok {
+		return // 範囲外などで数値として読めない値は警告済みなので集計しない
+	}
+	if s.Min == "" {
+		s.Min, s.Max = value.Text, value.Text
+		return
+	}
+	if c, _ := s.ordered(value.Text, s.Min); c < 0 {
+		s.Min = value.Text
+	}
+	if c, _ := s.ordered(value.Text, s.Max); c > 0 {
+		s.Max = value.Text
+	}
+}
+
+// Distinct は値の種類の数を返します。statsDistinctLimit を超えた場合は false を返します。
+func (s *ColumnStats) Distinct() (int, bool) {
+	if s.distinct == nil {
+		return 0, false
+	}
+	return len(s.distinct), true
+}
+
+// ComputeStats は SQL を書き出さずに入力を読み、変換後の値についてカラムごとの統計を求めます。
+func ComputeStats(schema []Schema, headerIndexMap map[string]int, inputReader *csv.Reader, options Options) ([]*ColumnStats, *Report, error) {
+	report := &Report{}
+	converter, err := newRowConverter(schema, headerIndexMap, options, report)
+	if err != nil {
+		return nil, report, err
+	}
+
+	stats := make([]*ColumnStats, 0, len(schema))
+	for _, column := range schema {
+		stats = append(stats, newColumnStats(column))
+	}
+
+	err = converter.each(inputReader, func(rowNumber int, values []Value) error {
+		for i, value := range values {
+			stats[i].add(value)
+		}
+		report.Rows++
+		return nil
+	})
+	if err != nil {
+		return nil, report, err
+	}
+	return stats, report, nil
+}
+
+// WriteStats は ComputeStats の結果を表形式で書き出します。
+func WriteStats(writer io.Writer, stats []*ColumnStats) error {
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "column\tempty\tmin\tmax\tdistinct")
+	for _, column := range stats {
+		distinct := fmt.Sprintf("more than %d", statsDistinctLimit)
+		if n, ok := column.Distinct(); ok {
+			distinct = fmt.Sprint(n)
+		}
+		fmt.Fprintf(table, "%s\t%d\t%s\t%s\t%s\n", column.Column, column.Empty, column.Min, column.Max, distinct)
+	}
+	return table.Flush()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// computeStats はスキーマと入力の CSV から ComputeStats を実行します。
+func computeStats(t *testing.T, schemaCSV, inputCSV string) []*ColumnStats {
+	t.Helper()
+	options := testOptions(t, "-stats")
+	schema := testSchema(t, schemaCSV, options)
+	reader := csv.NewReader(strings.NewReader(inputCSV))
+	headers, err := ParseHeaders(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, _, err := ComputeStats(schema, MapHeadersToSchema(headers, schema), reader, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stats
+}
+
+func TestComputeStats(t *testing.T) {
+	const schema = "id,int,id,INT\nprice,decimal,price,\"DECIMAL(10,2)\"\nname,nvarchar,name,VARCHAR(10)\ncreated,datetime,created,DATETIME\n"
+	const input = "id,price,name,created\n" +
+		"10,2.5,b,2023-01-02 03:04:05\n" +
+		"9,-1E+1,a,2022-12-31 23:59:59\n" +
+		"100,,b,\n" +
+		"x,10,,2023-06-01 00:00:00\n"
+	tests := []struct {
+		column   string
+		empty    int
+		min, max string
+		distinct int
+	}{
+		{"id", 0, "9", "100", 4}, // 読めない x は最小値・最大値に含めない
+		{"price", 1, "-10.00", "10.00", 3},
+		{"name", 1, "", "", 2}, // 文字列は最小値・最大値を求めない
+		{"created", 1, "2022-12-31 23:59:59", "2023-06-01 00:00:00", 3},
+	}
+	stats := computeStats(t, schema, input)
+	for i, tt := range tests {
+		got := stats[i]
+		distinct, _ := got.Distinct()
+		if got.Column != tt.column || got.Empty != tt.empty || got.Min != tt.min || got.Max != tt.max || distinct != tt.distinct {
+			t.Errorf("%s: empty %d, min %q, max %q, distinct %d; want %d, %q, %q, %d",
+				got.Column, got.Empty, got.Min, got.Max, distinct, tt.empty, tt.min, tt.max, tt.distinct)
+		}
+	}
+}
+
+func TestStatsDistinctLimit(t *testing.T) {
+	var input strings.Builder
+	input.WriteString("id\n")
+	for i := 0; i <= statsDistinctLimit; i++ {
+		fmt.Fprintf(&input, "%d\n", i)
+	}
+	stats := computeStats(t, "id,int,id,INT\n", input.String())
+
+	var output bytes.Buffer
+	if err := WriteStats(&output, stats); err != nil {
+		t.Fatal(err)
+	}
+	want := "column  empty  min  max  distinct\nid      0      0    100  more than 100\n"
+	if output.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", output.String(), want)
+	}
+}
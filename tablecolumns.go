@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ReadTableColumns は information_schema.columns をエクスポートした CSV から、
+// 転送先テーブルのカラム名を ORDINAL_POSITION の順に読み込みます。
+// ヘッダー行に COLUMN_NAME が必要で、ORDINAL_POSITION がなければ行の順序をそのまま使います。
+func ReadTableColumns(fileName string) ([]string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open table columns file: %s", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table columns file: %s", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("table columns file is empty")
+	}
+
+	nameIndex, positionIndex := -1, -1
+	for i, header := range records[0] {
+		switch strings.ToUpper(strings.TrimSpace(string(removeBOM([]byte(header))))) {
+		case "COLUMN_NAME":
+			nameIndex = i
+		case "ORDINAL_POSITION":
+			positionIndex = i
+		}
+	}
+	if nameIndex < 0 {
+		return nil, fmt.Errorf("table columns file has no COLUMN_NAME header")
+	}
+
+	type tableColumn struct {
+		name     string
+		position int
+	}
+	columns := make([]tableColumn, 0, len(records)-1)
+	for i, record := range records[1:] {
+		column := tableColumn{name: record[nameIndex], position: i + 1}
+		if positionIndex >= 0 {
+			position, err := strconv.Atoi(record[positionIndex])
+			if err != nil {
+				return nil, fmt.Errorf("table columns file line %d: invalid ORDINAL_POSITION %q", i+2, record[positionIndex])
+			}
+			column.position = position
+		}
+		columns = append(columns, column)
+	}
+	sort.SliceStable(columns, func(i, j int) bool {
+		return columns[i].position < columns[j].position
+	})
+
+	names := make([]string, 0, len(columns))
+	for _, column := range columns {
+		names = append(names, column.name)
+	}
+	return names, nil
+}
+
+// ReorderSchema はスキーマを転送先テーブルのカラム順に並べ替えます。
+// テーブルにないスキーマのカラムは末尾に残し、スキーマにないテーブルのカラムとあわせて警告を返します。
+func ReorderSchema(schema []Schema, tableColumns []string) ([]Schema, []Warning) {
+	byName := make(map[string]Schema, len(schema))
+	for _, column := range schema {
+		byName[strings.ToLower(column.ColumnTo)] = column
+	}
+
+	var warnings []Warning
+	reordered := make([]Schema, 0, len(schema))
+	inTable := make(map[string]bool, len(tableColumns))
+	for _, name := range tableColumns {
+		key := strings.ToLower(name)
+		inTable[key] = true
+		column, ok := byName[key]
+		if !ok {
+			warnings = append(warnings, Warning{Column: name, Message: "column is in the table but not in the schema"})
+			continue
+		}
+		reordered = append(reordered, column)
+	}
+
+	for _, column := range schema {
+		if !inTable[strings.ToLower(column.ColumnTo)] {
+			warnings = append(warnings, Warning{Column: column.ColumnTo, Message: "column is in the schema but not in the table"})
+			reordered = append(reordered, column)
+		}
+	}
+
+	return reordered, warnings
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadTableColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		dump    string
+		want    []string
+		wantErr bool
+	}{
+		{"ordinal position", "TABLE_NAME,COLUMN_NAME,ORDINAL_POSITION\nt,name,2\nt,created_at,3\nt,id,1\n", []string{"id", "name", "created_at"}, false},
+		{"row order", "\xEF\xBB\xBFcolumn_name\nid\nname\n", []string{"id", "name"}, false},
+		{"no column name", "TABLE_NAME,ORDINAL_POSITION\nt,1\n", nil, true},
+		{"invalid position", "COLUMN_NAME,ORDINAL_POSITION\nid,first\n", nil, true},
+		{"empty", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "columns.csv")
+			if err := os.WriteFile(path, []byte(tt.dump), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadTableColumns(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReorderSchema(t *testing.T) {
+	schema := testSchema(t, "Name,nvarchar,name,VARCHAR(10)\nNote,nvarchar,note,TEXT\nID,int,id,INT\n", Options{})
+	reordered, warnings := ReorderSchema(schema, []string{"ID", "name", "created_at"})
+
+	var names []string
+	for _, column := range reordered {
+		names = append(names, column.ColumnTo)
+	}
+	// 大文字小文字を区別せずに照合し、テーブルにないカラムは末尾に残す
+	if want := []string{"id", "name", "note"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("order = %q, want %q", names, want)
+	}
+	want := []Warning{
+		{Column: "created_at", Message: "column is in the table but not in the schema"},
+		{Column: "note", Message: "column is in the schema but not in the table"},
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %v, want %v", warnings, want)
+	}
+}
+
+func TestTableColumnsOrder(t *testing.T) {
+	files := map[string]string{
+		"input.csv":   "id,name\n1,a\n",
+		"schema.csv":  "name,nvarchar,name,VARCHAR(10)\nid,int,id,INT\n",
+		"columns.csv": "COLUMN_NAME,ORDINAL_POSITION\nid,1\nname,2\n",
+	}
+	if err := runConvert(t, files, "-table-columns", "columns.csv", "t", "input.csv", "schema.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if sql := readOutput(t, "t.SQL"); !strings.HasPrefix(sql, "INSERT INTO `t` (`id`, `name`)\nVALUES\n('1', 'a');") {
+		t.Errorf("output:\n%s", sql)
+	}
+}
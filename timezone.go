@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// validateTimezoneOptions は -source-tz/-target-tz を読み込みます。片方だけの指定はエラーにします。
+func validateTimezoneOptions(options *Options) error {
+	if (options.SourceTZ == "") != (options.TargetTZ == "") {
+		return fmt.Errorf("-source-tz and -target-tz must be used together")
+	}
+	if options.SourceTZ == "" {
+		return nil
+	}
+
+	var err error
+	if options.sourceLocation, err = time.LoadLocation(options.SourceTZ); err != nil {
+		return fmt.Errorf("invalid -source-tz %q: %s", options.SourceTZ, err)
+	}
+	if options.targetLocation, err = time.LoadLocation(options.TargetTZ); err != nil {
+		return fmt.Errorf("invalid -target-tz %q: %s", options.TargetTZ, err)
+	}
+	return nil
+}
+
+// shiftZone は -source-tz の壁時計の時刻として wall を読み、-target-tz の時刻に直します。
+// 夏時間の切り替えで存在しない時刻は、time パッケージの解釈で変換したうえでエラーを返します。
+func shiftZone(wall time.Time, options Options) (time.Time, error) {
+	t := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), options.sourceLocation)
+	// 存在しない時刻は time.Date が前後にずらすので、壁時計の値が変わる
+	if t.Format(datetimeOutputLayout) != wall.Format(datetimeOutputLayout) {
+		return t.In(options.targetLocation), fmt.Errorf("%s does not exist in %s because of a DST transition; converted as %s", wall.Format(datetimeOutputLayout), options.SourceTZ, t.Format(time.RFC3339))
+	}
+	return t.In(options.targetLocation), nil
+}
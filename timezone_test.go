@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShiftZone(t *testing.T) {
+	options := testOptions(t, "-source-tz", "America/New_York", "-target-tz", "UTC")
+	tests := []struct {
+		wall    string
+		want    string
+		wantErr bool
+	}{
+		{"2023-01-15 12:00:00", "2023-01-15 17:00:00", false}, // EST (-05:00)
+		{"2023-07-15 12:00:00", "2023-07-15 16:00:00", false}, // EDT (-04:00)
+		// 夏時間の開始直前と直後
+		{"2023-03-12 01:59:59", "2023-03-12 06:59:59", false},
+		{"2023-03-12 03:00:00", "2023-03-12 07:00:00", false},
+		// 02:00〜02:59 は存在せず、time パッケージは切り替え後の EDT として読む
+		{"2023-03-12 02:30:00", "2023-03-12 06:30:00", true},
+		// 夏時間の終了で 01:00〜01:59 は2回あり、先の EDT として読む
+		{"2023-11-05 00:59:59", "2023-11-05 04:59:59", false},
+		{"2023-11-05 01:30:00", "2023-11-05 05:30:00", false},
+		{"2023-11-05 02:00:00", "2023-11-05 07:00:00", false},
+	}
+	for _, tt := range tests {
+		wall, err := time.Parse(datetimeOutputLayout, tt.wall)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := shiftZone(wall, options)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("shiftZone(%s) error %v, want error %v", tt.wall, err, tt.wantErr)
+		}
+		if s := got.Format(datetimeOutputLayout); s != tt.want {
+			t.Errorf("shiftZone(%s) = %s, want %s", tt.wall, s, tt.want)
+		}
+	}
+}
+
+func TestTimezoneColumn(t *testing.T) {
+	sql, report, err := generate(t, "created,datetime,created,DATETIME\n", "created\n2023-03-12 01:30:00\n2023-03-12 02:30:00\n",
+		testOptions(t, "-source-tz", "America/New_York", "-target-tz", "Asia/Tokyo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sql, "('2023-03-12 15:30:00')") {
+		t.Errorf("got\n%s", sql)
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0].Row != 2 || !strings.Contains(report.Warnings[0].Message, "DST transition") {
+		t.Errorf("warnings %v, want a DST warning on row 2", report.Warnings)
+	}
+}
+
+func TestTimezoneOptionsInvalid(t *testing.T) {
+	for _, flags := range [][]string{
+		{"-source-tz", "America/New_York"},
+		{"-target-tz", "UTC"},
+		{"-source-tz", "Mars/Olympus", "-target-tz", "UTC"},
+	} {
+		if _, err := ParseArgs(append(append([]string{"convert"}, flags...), "t", "input.csv", "schema.csv")); err == nil {
+			t.Errorf("ParseArgs accepted %q", flags)
+		}
+	}
+}
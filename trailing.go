@@ -0,0 +1,16 @@
+package main
+
+import "fmt"
+
+// trimTrailingDelimiter は -trim-trailing-delimiter 指定時に、行末の区切り文字で生じた
+// 末尾の空のフィールドを取り除きます。区切り文字で終わらない行があれば、
+// 本物の空のカラムと区別できないのでエラーにします。rowNumber が 0 ならヘッダーです。
+func trimTrailingDelimiter(rowNumber int, record []string) ([]string, error) {
+	if len(record) > 0 && record[len(record)-1] == "" {
+		return record[:len(record)-1], nil
+	}
+	if rowNumber == 0 {
+		return nil, fmt.Errorf("-trim-trailing-delimiter: the header does not end with a delimiter")
+	}
+	return nil, fmt.Errorf("-trim-trailing-delimiter: row %d does not end with a delimiter, unlike the header", rowNumber)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTrimTrailingDelimiter(t *testing.T) {
+	tests := []struct {
+		rowNumber int
+		record    []string
+		want      []string
+		wantErr   string
+	}{
+		{0, []string{"id", "name", ""}, []string{"id", "name"}, ""},
+		{1, []string{"1", "", ""}, []string{"1", ""}, ""},
+		{0, []string{"id", "name"}, nil, "-trim-trailing-delimiter: the header does not end with a delimiter"},
+		{3, []string{"1", "a"}, nil, "-trim-trailing-delimiter: row 3 does not end with a delimiter, unlike the header"},
+	}
+	for _, tt := range tests {
+		got, err := trimTrailingDelimiter(tt.rowNumber, tt.record)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("trimTrailingDelimiter(%d, %q) error %v, want %q", tt.rowNumber, tt.record, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || strings.Join(got, ",") != strings.Join(tt.want, ",") || len(got) != len(tt.want) {
+			t.Errorf("trimTrailingDelimiter(%d, %q) = %q, %v; want %q", tt.rowNumber, tt.record, got, err, tt.want)
+		}
+	}
+}
+
+func TestTrimTrailingDelimiterFile(t *testing.T) {
+	const schema = "id,int,id,INT\nname,nvarchar,name,VARCHAR(10)\n"
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{"every line", "id,name,\n1,a,\n2,,\n", "('1', 'a'),\n('2', '')", ""},
+		{"row without delimiter", "id,name,\n1,a,\n2,b\n", "", "row 2 does not end with a delimiter"},
+		{"header without delimiter", "id,name\n1,a\n", "", "the header does not end with a delimiter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runConvert(t, map[string]string{"input.csv": tt.input, "schema.csv": schema}, "-trim-trailing-delimiter", "t", "input.csv", "schema.csv")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql := readOutput(t, "t.SQL"); !strings.Contains(sql, tt.want) {
+				t.Errorf("got\n%s\nwant %s", sql, tt.want)
+			}
+		})
+	}
+}
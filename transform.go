@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// Transform はスキーマの5列目以降で指定するカラム単位の値加工です。
+// default のように値を加工せず、出力方法だけを指定するものもあります。
+type Transform struct {
+	Name  string
+	Arg   string
+	apply func(string) string
+}
+
+// ParseTransform はスキーマに書かれた変換を解釈します。locale は upper/lower の大文字小文字の規則で、
+// upper:tr のように変換ごとに指定することもできます。
+func ParseTransform(spec string, locale language.Tag) (Transform, error) {
+	name, arg, _ := strings.Cut(spec, ":")
+
+	switch name {
+	case "trim":
+		if arg == "" {
+			return Transform{Name: name, apply: strings.TrimSpace}, nil
+		}
+		return Transform{Name: name, Arg: arg, apply: func(value string) string {
+			return strings.Trim(value, arg)
+		}}, nil
+	case "bool-enum":
+		// 0/1 を指定したメンバーに置き換える (例: bool-enum:no,yes)
+		members := strings.Split(arg, ",")
+		if len(members) != 2 {
+			return Transform{}, fmt.Errorf("invalid transform %s: expected bool-enum:member0,member1", spec)
+		}
+		return Transform{Name: name, Arg: arg, apply: func(value string) string {
+			switch value {
+			case "0":
+				return members[0]
+			case "1":
+				return members[1]
+			}
+			return value
+		}}, nil
+	case "regexp":
+		// regexp:pattern/replacement の形式。pattern 内の / は \/ と書く
+		pattern, replacement, ok := splitRegexpArg(arg)
+		if !ok {
+			return Transform{}, fmt.Errorf("invalid transform %s: expected regexp:pattern/replacement", spec)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return Transform{}, fmt.Errorf("invalid transform %s: %s", spec, err)
+		}
+		return Transform{Name: name, Arg: arg, apply: func(value string) string {
+			return re.ReplaceAllString(value, replacement)
+		}}, nil
+	case "upper", "lower":
+		// トルコ語の i/İ やドイツ語の ß など、言語ごとの規則に従って変換する
+		tag := locale
+		if arg != "" {
+			var err error
+			if tag, err = language.Parse(arg); err != nil {
+				return Transform{}, fmt.Errorf("invalid transform %s: %s", spec, err)
+			}
+		}
+		caser := cases.Lower
+		if name == "upper" {
+			caser = cases.Upper
+		}
+		return Transform{Name: name, Arg: arg, apply: func(value string) string {
+			// cases.Caser は状態を持つので、-parallel-within-file で共有しないよう呼ぶたびに作る
+			return caser(tag).String(value)
+		}}, nil
+	case "escape-like":
+		// 値を LIKE のパターンとして使う場合に、% と _ (とエスケープ文字自身)を文字どおりに一致させる。
+		// エスケープ文字は MySQL の既定の \ で、escape-like:! のように変更できる
+		escape := `\`
+		if arg != "" {
+			if utf8.RuneCountInString(arg) != 1 {
+				return Transform{}, fmt.Errorf("invalid transform %s: the escape character must be a single character", spec)
+			}
+			escape = arg
+		}
+		replacer := strings.NewReplacer(escape, escape+escape, "%", escape+"%", "_", escape+"_")
+		return Transform{Name: name, Arg: arg, apply: replacer.Replace}, nil
+	case "truncate", "truncate-bytes":
+		// truncate:N は N 文字、truncate-bytes:N は UTF-8 で N バイトまでに切り詰める。
+		// VARCHAR(N) の N は文字数だが、インデックスの長さの上限などはバイト数で決まる
+		length, err := strconv.Atoi(arg)
+		if err != nil || length < 0 {
+			return Transform{}, fmt.Errorf("invalid transform %s: expected %s:length", spec, name)
+		}
+		if name == "truncate-bytes" {
+			return Transform{Name: name, Arg: arg, apply: func(value string) string {
+				return truncateBytes(value, length)
+			}}, nil
+		}
+		return Transform{Name: name, Arg: arg, apply: func(value string) string {
+			return truncateRunes(value, length)
+		}}, nil
+	case "base":
+		// base:16 のように、N 進数の文字列で持っている数値を10進数にする。16進数の 0x は省略できる。
+		// 読めない値はそのまま残すので、整数のカラムでは convertInteger が警告する
+		base, err := strconv.Atoi(arg)
+		if err != nil || base < 2 || base > 36 {
+			return Transform{}, fmt.Errorf("invalid transform %s: expected base:N with N from 2 to 36", spec)
+		}
+		return Transform{Name: name, Arg: arg, apply: func(value string) string {
+			return fromBase(value, base)
+		}}, nil
+	case "flatten":
+		return Transform{Name: name, apply: flattenWhitespace}, nil
+	case "default":
+		// 空の値をクォートなしの DEFAULT として出力し、MySQL 側の既定値を使わせる
+		return Transform{Name: name, apply: keepValue}, nil
+	}
+
+	return Transform{}, fmt.Errorf("unknown transform: %s", spec)
+}
+
+func splitRegexpArg(arg string) (pattern, replacement string, ok bool) {
+	for i := 0; i < len(arg); i++ {
+		switch arg[i] {
+		case '\\':
+			i++
+		case '/':
+			return arg[:i], arg[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// flattenWhitespace は改行やタブ、全角スペースを含む空白の連続を半角スペース1つにまとめます。
+// 前後の空白は1つにまとめるだけで取り除きません(取り除く場合は trim と組み合わせます)。
+func flattenWhitespace(value string) string {
+	var result strings.Builder
+	inSpace := false
+	for _, r := range value {
+		if unicode.IsSpace(r) {
+			if !inSpace {
+				result.WriteByte(' ')
+			}
+			inSpace = true
+			continue
+		}
+		inSpace = false
+		result.WriteRune(r)
+	}
+	return result.String()
+}
+
+// truncateRunes は value を n 文字以下に切り詰めます。
+func truncateRunes(value string, n int) string {
+	for i := range value {
+		if n == 0 {
+			return value[:i]
+		}
+		n--
+	}
+	return value
+}
+
+// fromBase は base 進数の value を10進数の文字列にします。桁数の制限はありません。
+func fromBase(value string, base int) string {
+	digits := strings.TrimSpace(value)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if base == 16 && (strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X")) {
+		digits = digits[2:]
+	}
+	n, ok := new(big.Int).SetString(sign+digits, base)
+	if !ok || digits == "" || strings.ContainsAny(digits, "+-_") {
+		return value
+	}
+	return n.String()
+}
+
+func keepValue(value string) string {
+	return value
+}
+
+func (s Schema) HasTransform(name string) bool {
+	for _, transform := range s.Transforms {
+		if transform.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func applyTransforms(value string, transforms []Transform) string {
+	for _, transform := range transforms {
+		value = transform.apply(value)
+	}
+	return value
+}
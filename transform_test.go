@@ -0,0 +1,291 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+)
+
+// applyTransform は spec の変換を1つ解釈して value に適用します。
+func applyTransform(t *testing.T, spec, value string) string {
+	t.Helper()
+	transform, err := ParseTransform(spec, language.Und)
+	if err != nil {
+		t.Fatalf("ParseTransform(%q): %s", spec, err)
+	}
+	return transform.apply(value)
+}
+
+func TestTrimTransform(t *testing.T) {
+	tests := []struct {
+		spec  string
+		value string
+		want  string
+	}{
+		{"trim", "  abc \t", "abc"},
+		{"trim:#", "##123#", "123"},
+		{`trim:"`, `"quoted"`, "quoted"},
+		{`trim:"'`, `'"both"'`, "both"},
+		{"trim:#", "a#b", "a#b"},     // 前後にしか適用しない
+		{"trim:#", " #a# ", " #a# "}, // cutset を指定すると空白は取り除かない
+		{"trim:xy", "xyxzyx", "z"},
+	}
+	for _, tt := range tests {
+		if got := applyTransform(t, tt.spec, tt.value); got != tt.want {
+			t.Errorf("%s(%q) = %q, want %q", tt.spec, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestTrimTransformFromSchema(t *testing.T) {
+	schema, err := parseSchema([][]string{
+		{"code", "nvarchar", "code", "VARCHAR(10)", "trim:#"},
+		{"name", "nvarchar", "name", "VARCHAR(10)"},
+	}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := applyTransforms("#A1#", schema[0].Transforms); got != "A1" {
+		t.Errorf("code = %q, want %q", got, "A1")
+	}
+	if got := applyTransforms("#A1#", schema[1].Transforms); got != "#A1#" {
+		t.Errorf("name = %q, want the value unchanged", got)
+	}
+}
+
+func TestRegexpTransform(t *testing.T) {
+	tests := []struct {
+		spec  string
+		value string
+		want  string
+	}{
+		{`regexp:[^0-9]/`, "(03) 1234-5678", "0312345678"},
+		{`regexp:\s+/ `, "a  b\t\tc\n d", "a b c d"},
+		{`regexp:^(\d{3})(\d{4})$/$1-$2`, "1234567", "123-4567"},
+		{`regexp:\//-`, "2023/01/02", "2023-01-02"}, // パターン内の / は \/ と書く
+		{`regexp:x/`, "abc", "abc"},
+	}
+	for _, tt := range tests {
+		if got := applyTransform(t, tt.spec, tt.value); got != tt.want {
+			t.Errorf("%s(%q) = %q, want %q", tt.spec, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRegexpTransformInvalid(t *testing.T) {
+	for _, spec := range []string{"regexp:[0-9", "regexp:no-separator", "regexp:(?P<x/y"} {
+		if _, err := ParseTransform(spec, language.Und); err == nil {
+			t.Errorf("ParseTransform(%q) succeeded, want an error", spec)
+		}
+	}
+	// 不正なパターンは変換を始める前、スキーマを読んだ時点で断る
+	if _, err := parseSchema([][]string{{"phone", "nvarchar", "phone", "VARCHAR(20)", "regexp:[0-9/"}}, Options{}); err == nil {
+		t.Error("parseSchema accepted an invalid regexp")
+	}
+}
+
+func TestFlattenWhitespace(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"a  b", "a b"},
+		{"line1\r\nline2\n\nline3", "line1 line2 line3"},
+		{"a\t \tb", "a b"},
+		{"全角　空白", "全角 空白"},
+		{" lead and trail\n", " lead and trail "},
+		{"plain", "plain"},
+	}
+	for _, tt := range tests {
+		if got := applyTransform(t, "flatten", tt.value); got != tt.want {
+			t.Errorf("flatten(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFlattenWhitespaceOption(t *testing.T) {
+	const schema = "id,int,id,INT\nnote,nvarchar,note,VARCHAR(50)\ncode,nvarchar,code,INT\n"
+	const input = "id,note,code\n1,\"a\n\tb\",\"1 2\"\n"
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{nil, `('1', 'a\n	b', '1 2')`},
+		// 文字列の型のカラムだけを対象にする
+		{[]string{"-flatten-whitespace"}, `('1', 'a b', '1 2')`},
+	}
+	for _, tt := range tests {
+		sql, _, err := generate(t, schema, input, testOptions(t, tt.flags...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(sql, tt.want) {
+			t.Errorf("%q: got\n%s\nwant %s", tt.flags, sql, tt.want)
+		}
+	}
+}
+
+func TestCaseTransforms(t *testing.T) {
+	tests := []struct {
+		spec   string
+		locale language.Tag
+		value  string
+		want   string
+	}{
+		{"upper", language.Und, "istanbul", "ISTANBUL"},
+		{"upper", language.Turkish, "istanbul", "İSTANBUL"},
+		{"upper:tr", language.Und, "istanbul", "İSTANBUL"},
+		{"lower", language.Und, "DIYARBAKIR", "diyarbakir"},
+		{"lower", language.Turkish, "DIYARBAKIR", "dıyarbakır"},
+		{"lower:tr", language.Und, "İZMİR", "izmir"},
+		// 変換ごとの指定が -transform-locale より優先される
+		{"upper:en", language.Turkish, "istanbul", "ISTANBUL"},
+		{"upper", language.Und, "straße", "STRASSE"},
+		{"upper", language.German, "straße", "STRASSE"},
+		{"lower", language.Und, "STRASSE", "strasse"},
+		{"lower", language.Und, "Ǆ", "ǆ"},
+	}
+	for _, tt := range tests {
+		transform, err := ParseTransform(tt.spec, tt.locale)
+		if err != nil {
+			t.Fatalf("ParseTransform(%q): %s", tt.spec, err)
+		}
+		if got := transform.apply(tt.value); got != tt.want {
+			t.Errorf("%s with %s (%q) = %q, want %q", tt.spec, tt.locale, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestTransformLocaleOption(t *testing.T) {
+	const schema = "city,nvarchar,city,VARCHAR(20),upper\n"
+	sql, _, err := generate(t, schema, "city\nistanbul\n", testOptions(t, "-transform-locale", "tr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sql, "('İSTANBUL')") {
+		t.Errorf("got\n%s", sql)
+	}
+	if _, err := ParseArgs([]string{"convert", "-transform-locale", "not a locale", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted an invalid -transform-locale")
+	}
+	if _, err := ParseTransform("upper:!!", language.Und); err == nil {
+		t.Error("ParseTransform accepted an invalid locale")
+	}
+}
+
+func TestEscapeLikeTransform(t *testing.T) {
+	tests := []struct {
+		spec  string
+		value string
+		want  string
+	}{
+		{"escape-like", "100%", `100\%`},
+		{"escape-like", "a_b", `a\_b`},
+		{"escape-like", `C:\tmp_%`, `C:\\tmp\_\%`},
+		{"escape-like", "plain", "plain"},
+		{"escape-like:!", "50%_off!", "50!%!_off!!"},
+		{"escape-like:!", `a\b`, `a\b`},
+	}
+	for _, tt := range tests {
+		if got := applyTransform(t, tt.spec, tt.value); got != tt.want {
+			t.Errorf("%s(%q) = %q, want %q", tt.spec, tt.value, got, tt.want)
+		}
+	}
+	if _, err := ParseTransform("escape-like:!!", language.Und); err == nil {
+		t.Error("ParseTransform accepted a two-character escape")
+	}
+}
+
+func TestEscapeLikeColumn(t *testing.T) {
+	const schema = "pattern,nvarchar,pattern,VARCHAR(20),escape-like\nlabel,nvarchar,label,VARCHAR(20)\n"
+	sql, _, err := generate(t, schema, "pattern,label\n10%_off,10%_off\n", testOptions(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// エスケープした \ は文字列リテラルとしてもう一度エスケープされる
+	if !strings.Contains(sql, `('10\\%\\_off', '10%_off')`) {
+		t.Errorf("got\n%s", sql)
+	}
+}
+
+func TestTruncateTransforms(t *testing.T) {
+	tests := []struct {
+		spec  string
+		value string
+		want  string
+	}{
+		{"truncate:3", "abcdef", "abc"},
+		{"truncate-bytes:3", "abcdef", "abc"},
+		{"truncate:3", "日本語テキスト", "日本語"},
+		{"truncate-bytes:3", "日本語テキスト", "日"},
+		{"truncate-bytes:8", "日本語テキスト", "日本"}, // 3バイト目の途中では切らない
+		{"truncate:2", "😀😀😀", "😀😀"},
+		{"truncate-bytes:5", "😀😀😀", "😀"},
+		{"truncate:10", "short", "short"},
+		{"truncate-bytes:10", "short", "short"},
+		{"truncate:0", "abc", ""},
+	}
+	for _, tt := range tests {
+		got := applyTransform(t, tt.spec, tt.value)
+		if got != tt.want {
+			t.Errorf("%s(%q) = %q, want %q", tt.spec, tt.value, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("%s(%q) = %q is not valid UTF-8", tt.spec, tt.value, got)
+		}
+	}
+	for _, spec := range []string{"truncate", "truncate:-1", "truncate-bytes:x"} {
+		if _, err := ParseTransform(spec, language.Und); err == nil {
+			t.Errorf("ParseTransform(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestBaseTransform(t *testing.T) {
+	tests := []struct {
+		spec  string
+		value string
+		want  string
+	}{
+		{"base:16", "ff", "255"},
+		{"base:16", "0xFF", "255"},
+		{"base:16", " 0X1a ", "26"},
+		{"base:16", "-0x10", "-16"},
+		{"base:16", "ffffffffffffffff", "18446744073709551615"}, // 64ビットを超えても読む
+		{"base:2", "1010", "10"},
+		{"base:8", "0755", "493"},
+		{"base:36", "zz", "1295"},
+		// 読めない値はそのまま残す
+		{"base:16", "0x", "0x"},
+		{"base:16", "xyz", "xyz"},
+		{"base:16", "+ff", "+ff"},
+		{"base:16", "f_f", "f_f"},
+		{"base:2", "102", "102"},
+		{"base:16", "", ""},
+	}
+	for _, tt := range tests {
+		if got := applyTransform(t, tt.spec, tt.value); got != tt.want {
+			t.Errorf("%s(%q) = %q, want %q", tt.spec, tt.value, got, tt.want)
+		}
+	}
+	for _, spec := range []string{"base", "base:1", "base:37", "base:hex"} {
+		if _, err := ParseTransform(spec, language.Und); err == nil {
+			t.Errorf("ParseTransform(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestBaseTransformColumn(t *testing.T) {
+	sql, report, err := generate(t, "flags,int,flags,INT UNSIGNED,base:16\n", "flags\n0xFF\nzz\n", testOptions(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sql, "('255')") {
+		t.Errorf("got\n%s", sql)
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0].Row != 2 {
+		t.Errorf("warnings %v, want one for row 2", report.Warnings)
+	}
+}
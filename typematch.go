@@ -0,0 +1,402 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	StrictDatatypeMatchOff   = "off"
+	StrictDatatypeMatchWarn  = "warn"
+	StrictDatatypeMatchError = "error"
+)
+
+func validateStrictDatatypeMatch(mode string) error {
+	switch mode {
+	case StrictDatatypeMatchOff, StrictDatatypeMatchWarn, StrictDatatypeMatchError:
+		return nil
+	}
+	return fmt.Errorf("invalid -strict-datatype-match %q: must be %s, %s or %s", mode, StrictDatatypeMatchOff, StrictDatatypeMatchWarn, StrictDatatypeMatchError)
+}
+
+// MappingClass はスキーマの型の対応で、元の値がどこまでそのまま保てるかの分類です。
+type MappingClass int
+
+const (
+	MappingLossless MappingClass = iota // 値の範囲も意味も変わらない
+	MappingWidening                     // すべての値が入るが、範囲や精度が広がる、または型の意味が変わる
+	MappingLossy                        // 入らない値や、丸められる値がある
+)
+
+func (c MappingClass) String() string {
+	switch c {
+	case MappingLossless:
+		return "lossless"
+	case MappingWidening:
+		return "widening"
+	}
+	return "lossy"
+}
+
+// valueKind は型が保持する値の種類です。
+type valueKind int
+
+const (
+	kindUnknown valueKind = iota
+	kindInteger
+	kindDecimal
+	kindFloat
+	kindText
+	kindDate
+	kindDatetime
+	kindTime
+	kindUUID
+	kindBinary
+)
+
+// valueDomain は型が保持できる値の範囲です。種類ごとに使うフィールドが異なります。
+type valueDomain struct {
+	kind valueKind
+	name string
+
+	min              int64  // kindInteger の範囲
+	max              uint64 //
+	precision, scale int    // kindDecimal。kindFloat では仮数のビット数を precision に入れる
+	length           int    // kindText/kindBinary の文字数(バイト数)。-1 は事実上無制限、0 は不明
+	fraction         int    // kindDatetime/kindTime の小数秒の桁数。-1 は秒を持たない(smalldatetime)
+	offset           bool   // datetimeoffset のようにタイムゾーンのオフセットを持つ
+	narrowRange      bool   // MySQL の TIMESTAMP のように 1970〜2038 年しか持てない
+}
+
+// unboundedLength は nvarchar(max) や LONGTEXT の長さです。
+const unboundedLength = -1
+
+// sqlServerDomain は SQL Server の型(DataTypeFrom)の値の範囲を返します。
+func sqlServerDomain(definition string) valueDomain {
+	t := ParseDataType(definition)
+	d := valueDomain{name: strings.ToLower(t.Name)}
+	switch t.Name {
+	case "BIT":
+		d.kind, d.min, d.max = kindInteger, 0, 1
+	case "TINYINT":
+		d.kind, d.min, d.max = kindInteger, 0, 255
+	case "SMALLINT", "INT", "BIGINT":
+		bits, _ := integerBits(t.Name)
+		d.kind, d.min, d.max = kindInteger, -1<<(bits-1), 1<<(bits-1)-1
+	case "DECIMAL", "NUMERIC":
+		d.kind, d.precision, d.scale = kindDecimal, 18, 0 // SQL Server の既定値は decimal(18,0)
+		if len(t.Args) > 0 {
+			d.precision, d.scale = t.DecimalPrecision()
+		}
+	case "MONEY":
+		d.kind, d.precision, d.scale = kindDecimal, 19, 4
+	case "SMALLMONEY":
+		d.kind, d.precision, d.scale = kindDecimal, 10, 4
+	case "FLOAT":
+		d.kind, d.precision = kindFloat, 53
+		if n, ok := typeArg(t, 53); ok && n <= 24 {
+			d.precision = 24
+		}
+	case "REAL":
+		d.kind, d.precision = kindFloat, 24
+	case "CHAR", "VARCHAR", "NCHAR", "NVARCHAR":
+		d.kind = kindText
+		if len(t.Args) == 1 && strings.EqualFold(t.Args[0], "max") {
+			d.length = unboundedLength
+		} else if n, ok := typeArg(t, 0); ok {
+			d.length = n
+		}
+	case "TEXT", "NTEXT":
+		d.kind, d.length = kindText, unboundedLength
+	case "DATE":
+		d.kind = kindDate
+	case "SMALLDATETIME":
+		d.kind, d.fraction = kindDatetime, -1
+	case "DATETIME":
+		d.kind, d.fraction = kindDatetime, 3
+	case "DATETIME2", "DATETIMEOFFSET":
+		d.kind, d.fraction = kindDatetime, 7
+		if n, ok := typeArg(t, 7); ok {
+			d.fraction = n
+		}
+		d.offset = t.Name == "DATETIMEOFFSET"
+	case "TIME":
+		d.kind, d.fraction = kindTime, 7
+		if n, ok := typeArg(t, 7); ok {
+			d.fraction = n
+		}
+	case "UNIQUEIDENTIFIER":
+		d.kind = kindUUID
+	case "BINARY", "VARBINARY":
+		d.kind = kindBinary
+		if len(t.Args) == 1 && strings.EqualFold(t.Args[0], "max") {
+			d.length = unboundedLength
+		} else if n, ok := typeArg(t, 0); ok {
+			d.length = n
+		}
+	}
+	return d
+}
+
+// mysqlDomain は MySQL の型(DataTypeTo)の値の範囲を返します。
+func mysqlDomain(t DataType) valueDomain {
+	d := valueDomain{name: t.Name}
+	switch t.Name {
+	case "BOOL", "BOOLEAN":
+		d.kind, d.min, d.max = kindInteger, -128, 127
+	case "BIT":
+		n, _ := typeArg(t, 1)
+		d.kind, d.max = kindInteger, math.MaxUint64
+		if n < 64 {
+			d.max = 1<<n - 1
+		}
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT":
+		bits, _ := integerBits(t.Name)
+		d.kind = kindInteger
+		if t.Unsigned {
+			d.max = math.MaxUint64 >> (64 - bits)
+		} else {
+			d.min, d.max = -1<<(bits-1), 1<<(bits-1)-1
+		}
+	case "DECIMAL", "NUMERIC", "DEC", "FIXED":
+		d.kind = kindDecimal
+		d.precision, d.scale = t.DecimalPrecision()
+	case "FLOAT":
+		d.kind, d.precision = kindFloat, 24
+		if n, ok := typeArg(t, 24); ok && n > 24 {
+			d.precision = 53
+		}
+	case "DOUBLE", "REAL":
+		d.kind, d.precision = kindFloat, 53
+	case "CHAR", "VARCHAR", "NCHAR", "NVARCHAR":
+		d.kind = kindText
+		d.length, _ = t.CharLength()
+	// TEXT 系はバイト数の上限なので、utf8mb4 の1文字4バイトで割った文字数にする
+	case "TINYTEXT":
+		d.kind, d.length = kindText, 255/4
+	case "TEXT":
+		d.kind, d.length = kindText, 65535/4
+	case "MEDIUMTEXT":
+		d.kind, d.length = kindText, 16777215/4
+	case "LONGTEXT", "JSON":
+		d.kind, d.length = kindText, unboundedLength
+	case "DATE":
+		d.kind = kindDate
+	case "DATETIME", "TIMESTAMP":
+		d.kind = kindDatetime
+		d.fraction = fractionalScale(t)
+		d.narrowRange = t.Name == "TIMESTAMP"
+	case "TIME":
+		d.kind = kindTime
+		d.fraction = fractionalScale(t)
+	case "BINARY", "VARBINARY":
+		d.kind = kindBinary
+		d.length, _ = typeArg(t, 0)
+	case "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB":
+		d.kind, d.length = kindBinary, unboundedLength
+	}
+	return d
+}
+
+// typeArg は型の最初の引数を整数として返します。引数がなければ fallback を返します。
+func typeArg(t DataType, fallback int) (int, bool) {
+	if len(t.Args) == 0 {
+		return fallback, fallback > 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(t.Args[0]))
+	if err != nil {
+		return fallback, false
+	}
+	return n, true
+}
+
+// ClassifyMapping は DataTypeFrom から DataTypeTo への対応を分類し、lossless 以外では理由も返します。
+// 対応表にない組み合わせは、値が保てるか判断できないので lossy とします。
+func ClassifyMapping(dataTypeFrom, dataTypeTo string) (MappingClass, string) {
+	src, dest := sqlServerDomain(dataTypeFrom), mysqlDomain(ParseDataType(dataTypeTo))
+	if src.kind == kindUnknown || dest.kind == kindUnknown {
+		return MappingLossy, "no compatibility rule for these types"
+	}
+
+	if dest.kind == kindText && src.kind != kindText {
+		return compareLength(src.renderedLength(), dest.length, "as text")
+	}
+
+	switch src.kind {
+	case kindInteger:
+		switch dest.kind {
+		case kindInteger:
+			switch {
+			case src.min == dest.min && src.max == dest.max:
+				return MappingLossless, ""
+			case src.min >= dest.min && src.max <= dest.max:
+				return MappingWidening, fmt.Sprintf("the range widens to %d..%d", dest.min, dest.max)
+			}
+			return MappingLossy, fmt.Sprintf("values outside %d..%d do not fit", dest.min, dest.max)
+		case kindDecimal:
+			if digits := len(strconv.FormatUint(src.magnitude(), 10)); digits <= dest.precision-dest.scale {
+				return MappingWidening, "integers become decimals"
+			}
+			return MappingLossy, fmt.Sprintf("DECIMAL(%d,%d) has too few integer digits", dest.precision, dest.scale)
+		case kindFloat:
+			if src.magnitude() <= 1<<dest.precision {
+				return MappingWidening, "integers become floating point"
+			}
+			return MappingLossy, "large integers are rounded by floating point"
+		}
+	case kindDecimal:
+		switch dest.kind {
+		case kindDecimal:
+			switch {
+			case src.precision == dest.precision && src.scale == dest.scale:
+				return MappingLossless, ""
+			case dest.precision-dest.scale >= src.precision-src.scale && dest.scale >= src.scale:
+				return MappingWidening, fmt.Sprintf("the precision widens to DECIMAL(%d,%d)", dest.precision, dest.scale)
+			}
+			return MappingLossy, fmt.Sprintf("DECIMAL(%d,%d) cannot hold every %s value", dest.precision, dest.scale, src.name)
+		case kindInteger:
+			if src.scale > 0 {
+				return MappingLossy, "fractional digits are dropped"
+			}
+			if math.Pow(10, float64(src.precision))-1 <= math.Min(-float64(dest.min), float64(dest.max)) {
+				return MappingWidening, "decimals become integers"
+			}
+			return MappingLossy, fmt.Sprintf("values outside %d..%d do not fit", dest.min, dest.max)
+		case kindFloat:
+			return MappingLossy, "decimals are rounded by floating point"
+		}
+	case kindFloat:
+		if dest.kind == kindFloat {
+			switch {
+			case src.precision == dest.precision:
+				return MappingLossless, ""
+			case src.precision < dest.precision:
+				return MappingWidening, "the precision widens to DOUBLE"
+			}
+			return MappingLossy, "FLOAT keeps only single precision"
+		}
+		return MappingLossy, "floating point values may not be exact in " + dest.name
+	case kindText:
+		if dest.kind == kindText {
+			if src.length == 0 {
+				return MappingLossy, "the source length is not declared; write it as " + src.name + "(n) or " + src.name + "(max)"
+			}
+			return compareLength(src.length, dest.length, "")
+		}
+		return MappingLossy, "text that does not parse as " + dest.name + " is not preserved"
+	case kindDate, kindDatetime:
+		return compareDateDomains(src, dest)
+	case kindTime:
+		if dest.kind == kindTime {
+			return compareFraction(src.fraction, dest.fraction)
+		}
+	case kindUUID:
+		if dest.kind == kindBinary && dest.length == 16 {
+			return MappingLossless, ""
+		}
+	case kindBinary:
+		if dest.kind == kindBinary {
+			return compareLength(src.length, dest.length, "")
+		}
+	}
+	return MappingLossy, fmt.Sprintf("%s values do not map to %s", src.name, dest.name)
+}
+
+func compareDateDomains(src, dest valueDomain) (MappingClass, string) {
+	switch {
+	case dest.kind != kindDate && dest.kind != kindDatetime:
+		return MappingLossy, fmt.Sprintf("%s values do not map to %s", src.name, dest.name)
+	case src.offset:
+		return MappingLossy, "the time zone offset is dropped"
+	case dest.narrowRange:
+		return MappingLossy, "TIMESTAMP only holds 1970 to 2038 and depends on the session time zone"
+	case src.kind == kindDate && dest.kind == kindDate:
+		return MappingLossless, ""
+	case src.kind == kindDate:
+		return MappingWidening, "dates gain a time of day"
+	case dest.kind == kindDate:
+		return MappingLossy, "the time of day is dropped"
+	}
+	return compareFraction(src.fraction, dest.fraction)
+}
+
+func compareFraction(src, dest int) (MappingClass, string) {
+	switch {
+	case src == dest:
+		return MappingLossless, ""
+	case src < dest:
+		return MappingWidening, fmt.Sprintf("fractional seconds widen to %d digits", dest)
+	}
+	return MappingLossy, fmt.Sprintf("fractional seconds are reduced to %d digits", dest)
+}
+
+func compareLength(src, dest int, suffix string) (MappingClass, string) {
+	if suffix != "" {
+		suffix = " " + suffix
+	}
+	switch {
+	case dest == 0:
+		return MappingLossy, "the destination length is not declared"
+	case src == dest:
+		if suffix != "" {
+			return MappingWidening, "values are stored" + suffix
+		}
+		return MappingLossless, ""
+	case dest == unboundedLength || (src != unboundedLength && src < dest):
+		return MappingWidening, fmt.Sprintf("values are stored%s in a longer column", suffix)
+	}
+	return MappingLossy, fmt.Sprintf("values longer than %d are truncated or rejected%s", dest, suffix)
+}
+
+// magnitude は整数の範囲の絶対値の最大です。
+func (d valueDomain) magnitude() uint64 {
+	return max(uint64(-(d.min+1))+1, d.max)
+}
+
+// renderedLength は数値や日時を文字列にしたときの最大の文字数です。
+func (d valueDomain) renderedLength() int {
+	switch d.kind {
+	case kindInteger:
+		return max(len(strconv.FormatInt(d.min, 10)), len(strconv.FormatUint(d.max, 10)))
+	case kindDecimal:
+		return d.precision + 2 // 符号と小数点
+	case kindFloat:
+		return 24
+	case kindDate:
+		return len("2006-01-02")
+	case kindDatetime, kindTime:
+		length := len("15:04:05")
+		if d.kind == kindDatetime {
+			length += len("2006-01-02 ")
+		}
+		if d.fraction > 0 {
+			length += 1 + d.fraction
+		}
+		if d.offset {
+			length += len(" +09:00")
+		}
+		return length
+	case kindUUID:
+		return 36
+	}
+	return unboundedLength
+}
+
+// CheckDatatypeMatch は -strict-datatype-match で、lossless でない型の対応を警告として返します。
+// allowWidening なら widening の対応は知らせません。
+func CheckDatatypeMatch(schema []Schema, allowWidening bool) []Warning {
+	var warnings []Warning
+	for _, column := range schema {
+		class, reason := ClassifyMapping(column.DataTypeFrom, column.DataTypeTo)
+		if class == MappingLossless || (class == MappingWidening && allowWidening) {
+			continue
+		}
+		warnings = append(warnings, Warning{
+			Column:  column.ColumnTo,
+			Message: fmt.Sprintf("%s -> %s is %s: %s", column.DataTypeFrom, column.DataTypeTo, class, reason),
+		})
+	}
+	return warnings
+}
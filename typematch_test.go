@@ -0,0 +1,122 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassifyMapping(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     MappingClass
+	}{
+		{"int", "INT", MappingLossless},
+		{"int", "BIGINT", MappingWidening},
+		{"bigint", "INT", MappingLossy},
+		{"tinyint", "TINYINT UNSIGNED", MappingLossless},
+		{"tinyint", "TINYINT", MappingLossy},
+		{"bit", "TINYINT(1)", MappingWidening},
+		{"bit", "BIT(1)", MappingLossless},
+		{"int", "VARCHAR(11)", MappingWidening},
+		{"int", "VARCHAR(5)", MappingLossy},
+		{"int", "DECIMAL(10,0)", MappingWidening},
+		{"int", "DECIMAL(5,2)", MappingLossy},
+		{"int", "DOUBLE", MappingWidening},
+		{"bigint", "DOUBLE", MappingLossy},
+		{"decimal(10,2)", "DECIMAL(10,2)", MappingLossless},
+		{"decimal(10,2)", "DECIMAL(12,4)", MappingWidening},
+		{"decimal(10,2)", "DECIMAL(10,1)", MappingLossy},
+		{"decimal(9,0)", "INT", MappingWidening},
+		{"decimal(10,2)", "INT", MappingLossy},
+		{"money", "DECIMAL(19,4)", MappingLossless},
+		{"money", "DOUBLE", MappingLossy},
+		{"real", "FLOAT", MappingLossless},
+		{"real", "DOUBLE", MappingWidening},
+		{"float", "FLOAT", MappingLossy},
+		{"nvarchar(50)", "VARCHAR(50)", MappingLossless},
+		{"nvarchar(50)", "VARCHAR(100)", MappingWidening},
+		{"nvarchar(50)", "VARCHAR(20)", MappingLossy},
+		{"nvarchar(max)", "LONGTEXT", MappingLossless},
+		{"nvarchar(max)", "VARCHAR(255)", MappingLossy},
+		{"nvarchar", "VARCHAR(50)", MappingLossy}, // 元の長さが分からない
+		{"nvarchar(10)", "INT", MappingLossy},
+		{"date", "DATE", MappingLossless},
+		{"date", "DATETIME", MappingWidening},
+		{"datetime", "DATETIME(3)", MappingLossless},
+		{"datetime", "DATETIME", MappingLossy},
+		{"datetime", "DATE", MappingLossy},
+		{"datetime", "VARCHAR(23)", MappingWidening},
+		{"datetime", "VARCHAR(10)", MappingLossy},
+		{"datetime", "TIMESTAMP(3)", MappingLossy},
+		{"smalldatetime", "DATETIME", MappingWidening},
+		{"datetime2", "DATETIME(6)", MappingLossy},
+		{"datetimeoffset", "DATETIME(6)", MappingLossy},
+		{"time(3)", "TIME(3)", MappingLossless},
+		{"time(3)", "TIME(6)", MappingWidening},
+		{"uniqueidentifier", "BINARY(16)", MappingLossless},
+		{"uniqueidentifier", "CHAR(36)", MappingWidening},
+		{"uniqueidentifier", "CHAR(32)", MappingLossy},
+		{"varbinary(16)", "VARBINARY(16)", MappingLossless},
+		{"varbinary(max)", "LONGBLOB", MappingLossless},
+		{"xml", "LONGTEXT", MappingLossy}, // 対応表にない
+	}
+	for _, tt := range tests {
+		got, reason := ClassifyMapping(tt.from, tt.to)
+		if got != tt.want {
+			t.Errorf("ClassifyMapping(%s, %s) = %s (%s), want %s", tt.from, tt.to, got, reason, tt.want)
+		}
+		if (got == MappingLossless) != (reason == "") {
+			t.Errorf("ClassifyMapping(%s, %s) = %s with reason %q", tt.from, tt.to, got, reason)
+		}
+	}
+}
+
+func TestCheckDatatypeMatch(t *testing.T) {
+	schema := testSchema(t, "id,int,id,INT\ncount,int,count,BIGINT\ncode,bigint,code,INT\n", Options{})
+	tests := []struct {
+		allowWidening bool
+		want          []string
+	}{
+		{false, []string{"count", "code"}},
+		{true, []string{"code"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, warning := range CheckDatatypeMatch(schema, tt.allowWidening) {
+			got = append(got, warning.Column)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("allowWidening %v: warnings for %v, want %v", tt.allowWidening, got, tt.want)
+		}
+	}
+}
+
+func TestStrictDatatypeMatchOption(t *testing.T) {
+	files := map[string]string{
+		"input.csv":  "id,code\n1,2\n",
+		"schema.csv": "id,int,id,BIGINT\ncode,bigint,code,INT\n",
+	}
+	tests := []struct {
+		flags   []string
+		wantErr bool
+	}{
+		{nil, false},
+		{[]string{"-strict-datatype-match", "warn"}, false},
+		{[]string{"-strict-datatype-match", "error"}, true},
+		{[]string{"-strict-datatype-match", "error", "-allow-widening"}, true},
+	}
+	for _, tt := range tests {
+		args := append(tt.flags, "t", "input.csv", "schema.csv")
+		if err := runConvert(t, files, args...); (err != nil) != tt.wantErr {
+			t.Errorf("%q: error %v, wantErr %v", tt.flags, err, tt.wantErr)
+		}
+	}
+
+	files["schema.csv"] = "id,int,id,BIGINT\ncode,bigint,code,BIGINT\n"
+	if err := runConvert(t, files, "-strict-datatype-match", "error", "-allow-widening", "t", "input.csv", "schema.csv"); err != nil {
+		t.Errorf("-allow-widening rejected a widening mapping: %s", err)
+	}
+	if _, err := ParseArgs([]string{"convert", "-strict-datatype-match", "strict", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted -strict-datatype-match strict")
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// stringList は繰り返し指定できるフラグの値です。
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func validateUpsertOptions(options Options) error {
+	if !options.Upsert && (options.UpsertExclude != "" || len(options.UpsertSet) > 0) {
+		return fmt.Errorf("-upsert-exclude and -upsert-set require -upsert")
+	}
+	for _, assignment := range options.UpsertSet {
+		column, expression, ok := strings.Cut(assignment, "=")
+		if !ok || strings.TrimSpace(column) == "" || strings.TrimSpace(expression) == "" {
+			return fmt.Errorf("invalid -upsert-set %q: expected column=expression", assignment)
+		}
+	}
+	if !options.Upsert && options.UpsertBinaryKey != "" {
+		return fmt.Errorf("-upsert-binary-key requires -upsert")
+	}
+	if !charsetNamePattern.MatchString(options.UpsertKeyCollation) {
+		return fmt.Errorf("invalid -upsert-key-collation %q", options.UpsertKeyCollation)
+	}
+	if options.Upsert && options.LoadData {
+		return fmt.Errorf("-upsert cannot be used with -load-data")
+	}
+	return nil
+}
+
+// onDuplicateKeyUpdate は -upsert 指定時に INSERT の末尾に付ける ON DUPLICATE KEY UPDATE 句を返します。
+// -upsert-exclude のカラムは更新せず(created_at など)、-upsert-set のカラムは指定した式で更新します(updated_at = NOW() など)。
+func onDuplicateKeyUpdate(schema []Schema, options Options) (string, error) {
+	if !options.Upsert {
+		return "", nil
+	}
+
+	columnNames := make(map[string]bool, len(schema))
+	for _, column := range schema {
+		columnNames[column.ColumnTo] = true
+	}
+
+	excluded := make(map[string]bool)
+	if options.UpsertExclude != "" {
+		for _, name := range strings.Split(options.UpsertExclude, ",") {
+			name = strings.TrimSpace(name)
+			if !columnNames[name] {
+				return "", fmt.Errorf("-upsert-exclude column %q is not in the schema", name)
+			}
+			excluded[name] = true
+		}
+	}
+
+	expressions := make(map[string]string)
+	var extraColumns []string
+	for _, assignment := range options.UpsertSet {
+		column, expression, _ := strings.Cut(assignment, "=")
+		column, expression = strings.TrimSpace(column), strings.TrimSpace(expression)
+		if _, ok := expressions[column]; !ok && !columnNames[column] {
+			extraColumns = append(extraColumns, column)
+		}
+		expressions[column] = expression
+	}
+
+	// -upsert-binary-key のカラムが大文字小文字・アクセントまで一致する場合だけ更新する。
+	// 一意インデックスが _ci の照合順序で 'ABC' と 'abc' を同じキーとみなしても、既存の行は書き換えない。
+	var keyConditions []string
+	if options.UpsertBinaryKey != "" {
+		for _, name := range strings.Split(options.UpsertBinaryKey, ",") {
+			name = strings.TrimSpace(name)
+			if !columnNames[name] {
+				return "", fmt.Errorf("-upsert-binary-key column %q is not in the schema", name)
+			}
+			quoted := options.QuoteIdentifier(name)
+			keyConditions = append(keyConditions, fmt.Sprintf("%s = VALUES(%s) COLLATE %s", quoted, quoted, options.UpsertKeyCollation))
+		}
+	}
+	assign := func(name, expression string) string {
+		quoted := options.QuoteIdentifier(name)
+		if len(keyConditions) == 0 {
+			return fmt.Sprintf("%s = %s", quoted, expression)
+		}
+		return fmt.Sprintf("%s = IF(%s, %s, %s)", quoted, strings.Join(keyConditions, " AND "), expression, quoted)
+	}
+
+	var assignments []string
+	for _, column := range schema {
+		name := column.ColumnTo
+		switch {
+		case expressions[name] != "":
+			assignments = append(assignments, assign(name, expressions[name]))
+		case excluded[name]:
+		default:
+			assignments = append(assignments, assign(name, "VALUES("+options.QuoteIdentifier(name)+")"))
+		}
+	}
+	for _, name := range extraColumns {
+		assignments = append(assignments, assign(name, expressions[name]))
+	}
+
+	if len(assignments) == 0 {
+		return "", fmt.Errorf("-upsert has no columns left to update")
+	}
+	return "\nON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", "), nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const upsertSchema = `id,int,id,INT
+name,nvarchar,name,VARCHAR(20)
+created_at,datetime,created_at,DATETIME
+updated_at,datetime,updated_at,DATETIME
+`
+
+func TestUpsertClause(t *testing.T) {
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{
+			[]string{"-upsert"},
+			"ON DUPLICATE KEY UPDATE `id` = VALUES(`id`), `name` = VALUES(`name`), `created_at` = VALUES(`created_at`), `updated_at` = VALUES(`updated_at`)",
+		},
+		{
+			[]string{"-upsert", "-upsert-exclude", "id,created_at", "-upsert-set", "updated_at=NOW()"},
+			"ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `updated_at` = NOW()",
+		},
+		{
+			// スキーマにないカラムも式で更新できる
+			[]string{"-upsert", "-upsert-exclude", "created_at", "-upsert-set", "version = version + 1"},
+			"ON DUPLICATE KEY UPDATE `id` = VALUES(`id`), `name` = VALUES(`name`), `updated_at` = VALUES(`updated_at`), `version` = version + 1",
+		},
+	}
+	for _, tt := range tests {
+		options := testOptions(t, tt.flags...)
+		clause, err := onDuplicateKeyUpdate(testSchema(t, upsertSchema, options), options)
+		if err != nil {
+			t.Fatalf("%q: %s", tt.flags, err)
+		}
+		if want := "\n" + tt.want; clause != want {
+			t.Errorf("%q:\n got %q\nwant %q", tt.flags, clause, want)
+		}
+	}
+}
+
+func TestUpsertStatement(t *testing.T) {
+	options := testOptions(t, "-upsert", "-upsert-exclude", "created_at", "-upsert-set", "updated_at=NOW()")
+	sql, _, err := generate(t, upsertSchema, "id,name,created_at,updated_at\n1,a,2023-01-01 00:00:00,2023-01-01 00:00:00\n", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(sql, "\nON DUPLICATE KEY UPDATE `id` = VALUES(`id`), `name` = VALUES(`name`), `updated_at` = NOW();\n") {
+		t.Errorf("output:\n%s", sql)
+	}
+	if strings.Contains(sql, "`created_at` = ") {
+		t.Errorf("created_at is updated:\n%s", sql)
+	}
+}
+
+func TestUpsertOptionErrors(t *testing.T) {
+	tests := [][]string{
+		{"-upsert-exclude", "created_at"},
+		{"-upsert-set", "updated_at=NOW()"},
+		{"-upsert", "-upsert-set", "updated_at"},
+		{"-upsert", "-upsert-set", "=NOW()"},
+	}
+	for _, flags := range tests {
+		if _, err := ParseArgs(append(append([]string{"convert"}, flags...), "t", "input.csv", "schema.csv")); err == nil {
+			t.Errorf("ParseArgs accepted %q", flags)
+		}
+	}
+
+	schemaErrors := [][]string{
+		{"-upsert", "-upsert-exclude", "missing"},
+		{"-upsert", "-upsert-exclude", "id,name,created_at,updated_at"},
+	}
+	for _, flags := range schemaErrors {
+		options := testOptions(t, flags...)
+		if _, err := onDuplicateKeyUpdate(testSchema(t, upsertSchema, options), options); err == nil {
+			t.Errorf("onDuplicateKeyUpdate accepted %q", flags)
+		}
+	}
+}
+
+func TestUpsertBinaryKey(t *testing.T) {
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{
+			[]string{"-upsert", "-upsert-exclude", "id,created_at,updated_at", "-upsert-binary-key", "name"},
+			"ON DUPLICATE KEY UPDATE `name` = IF(`name` = VALUES(`name`) COLLATE utf8mb4_bin, VALUES(`name`), `name`)",
+		},
+		{
+			[]string{"-upsert", "-upsert-exclude", "created_at,updated_at", "-upsert-binary-key", "id, name", "-upsert-key-collation", "utf8mb4_0900_as_cs"},
+			"ON DUPLICATE KEY UPDATE " +
+				"`id` = IF(`id` = VALUES(`id`) COLLATE utf8mb4_0900_as_cs AND `name` = VALUES(`name`) COLLATE utf8mb4_0900_as_cs, VALUES(`id`), `id`), " +
+				"`name` = IF(`id` = VALUES(`id`) COLLATE utf8mb4_0900_as_cs AND `name` = VALUES(`name`) COLLATE utf8mb4_0900_as_cs, VALUES(`name`), `name`)",
+		},
+		{
+			[]string{"-upsert", "-upsert-exclude", "id,name,created_at", "-upsert-set", "updated_at=NOW()", "-upsert-binary-key", "name"},
+			"ON DUPLICATE KEY UPDATE `updated_at` = IF(`name` = VALUES(`name`) COLLATE utf8mb4_bin, NOW(), `updated_at`)",
+		},
+	}
+	for _, tt := range tests {
+		options := testOptions(t, tt.flags...)
+		clause, err := onDuplicateKeyUpdate(testSchema(t, upsertSchema, options), options)
+		if err != nil {
+			t.Fatalf("%q: %s", tt.flags, err)
+		}
+		if want := "\n" + tt.want; clause != want {
+			t.Errorf("%q:\n got %q\nwant %q", tt.flags, clause, want)
+		}
+	}
+}
+
+func TestUpsertBinaryKeyErrors(t *testing.T) {
+	if _, err := ParseArgs([]string{"convert", "-upsert-binary-key", "name", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("ParseArgs accepted -upsert-binary-key without -upsert")
+	}
+	options := testOptions(t, "-upsert", "-upsert-binary-key", "code")
+	if _, err := onDuplicateKeyUpdate(testSchema(t, upsertSchema, options), options); err == nil {
+		t.Error("onDuplicateKeyUpdate accepted a key column that is not in the schema")
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import "fmt"
+
+const (
+	FourByteCheckOff   = "off"
+	FourByteCheckWarn  = "warn"
+	FourByteCheckError = "error"
+)
+
+// findFourByteRune は utf8mb4 でないと格納できない4バイトの UTF-8 文字(絵文字など)を探します。
+// utf8(utf8mb3) のカラムに入れると文字列がそこで切り詰められます。
+func findFourByteRune(value string) (rune, bool) {
+	for _, r := range value {
+		if r > 0xFFFF {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+func validateFourByteCheck(mode string) error {
+	switch mode {
+	case FourByteCheckOff, FourByteCheckWarn, FourByteCheckError:
+		return nil
+	}
+	return fmt.Errorf("invalid -force-utf8mb4-4byte-check %q: must be %s, %s or %s", mode, FourByteCheckOff, FourByteCheckWarn, FourByteCheckError)
+}
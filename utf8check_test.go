@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindFourByteRune(t *testing.T) {
+	tests := []struct {
+		value string
+		want  rune
+		found bool
+	}{
+		{"plain ascii", 0, false},
+		{"日本語", 0, false},
+		{"smile 😀", '😀', true},
+		{"𠮷野家", '𠮷', true},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		r, found := findFourByteRune(tt.value)
+		if r != tt.want || found != tt.found {
+			t.Errorf("findFourByteRune(%q) = %U, %v; want %U, %v", tt.value, r, found, tt.want, tt.found)
+		}
+	}
+}
+
+func TestFourByteCheck(t *testing.T) {
+	const schema = "name,nvarchar,name,VARCHAR(20)\n"
+	const input = "name\nok\nsmile 😀\n"
+	tests := []struct {
+		mode     string
+		warnings int
+		wantErr  string
+	}{
+		{FourByteCheckOff, 0, ""},
+		{FourByteCheckWarn, 1, ""},
+		{FourByteCheckError, 0, "row 2, column name: value contains the 4-byte character U+1F600"},
+	}
+	for _, tt := range tests {
+		sql, report, err := generate(t, schema, input, testOptions(t, "-force-utf8mb4-4byte-check", tt.mode))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error %v, want %q", tt.mode, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", tt.mode, err)
+			continue
+		}
+		if len(report.Warnings) != tt.warnings {
+			t.Errorf("%s: warnings %v, want %d", tt.mode, report.Warnings, tt.warnings)
+		}
+		if !strings.Contains(sql, "'smile 😀'") {
+			t.Errorf("%s: value was not written unchanged:\n%s", tt.mode, sql)
+		}
+	}
+}
+
+func TestValidateFourByteCheck(t *testing.T) {
+	if _, err := ParseArgs([]string{"convert", "-force-utf8mb4-4byte-check", "strict", "t", "input.csv", "schema.csv"}); err == nil {
+		t.Error("invalid mode was accepted")
+	}
+}
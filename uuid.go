@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// convertUUIDToBinary は uniqueidentifier を BINARY(16) 向けに変換します。
+// -uuid-swap を指定すると UUID_TO_BIN(uuid, 1) と同じく time_low と time_high を入れ替え、
+// 値がおおむね時刻順に並ぶようにしてインデックスの局所性を高めます。
+func convertUUIDToBinary(value string, options Options) (Value, error) {
+	if value == "" {
+		return Value{Text: value}, nil
+	}
+
+	uuid := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(value), "{"), "}")
+	if !uuidPattern.MatchString(uuid) {
+		return Value{Text: value}, fmt.Errorf("%q is not a valid uniqueidentifier", value)
+	}
+	uuid = strings.ToLower(uuid)
+
+	if options.UUIDToBin {
+		if options.UUIDSwap {
+			return Value{Text: fmt.Sprintf("UUID_TO_BIN('%s', 1)", uuid), Kind: KeywordValue}, nil
+		}
+		return Value{Text: fmt.Sprintf("UUID_TO_BIN('%s')", uuid), Kind: KeywordValue}, nil
+	}
+
+	hex := strings.ReplaceAll(uuid, "-", "")
+	if options.UUIDSwap {
+		// time_high + time_mid + time_low + clock_seq + node
+		hex = hex[12:16] + hex[8:12] + hex[0:8] + hex[16:]
+	}
+	return Value{Text: "X'" + hex + "'", Kind: KeywordValue}, nil
+}
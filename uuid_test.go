@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertUUIDToBinary(t *testing.T) {
+	// MySQL のマニュアルの UUID_TO_BIN の例と同じ値
+	const uuid = "6CCD780C-BABA-1026-9564-5B8C656024DB"
+	tests := []struct {
+		flags []string
+		value string
+		want  string
+	}{
+		{nil, uuid, "X'6ccd780cbaba102695645b8c656024db'"},
+		{[]string{"-uuid-swap"}, uuid, "X'1026baba6ccd780c95645b8c656024db'"},
+		{nil, "{" + uuid + "}", "X'6ccd780cbaba102695645b8c656024db'"},
+		{[]string{"-uuid-to-bin"}, uuid, "UUID_TO_BIN('6ccd780c-baba-1026-9564-5b8c656024db')"},
+		{[]string{"-uuid-to-bin", "-uuid-swap"}, uuid, "UUID_TO_BIN('6ccd780c-baba-1026-9564-5b8c656024db', 1)"},
+	}
+	for _, tt := range tests {
+		got, err := convertUUIDToBinary(tt.value, testOptions(t, tt.flags...))
+		if err != nil {
+			t.Errorf("%q: %s", tt.flags, err)
+			continue
+		}
+		if got.Text != tt.want || got.Kind != KeywordValue {
+			t.Errorf("%q: got %q (kind %d), want unquoted %q", tt.flags, got.Text, got.Kind, tt.want)
+		}
+	}
+}
+
+func TestConvertUUIDToBinaryInvalid(t *testing.T) {
+	for _, value := range []string{"6ccd780c-baba-1026-9564", "not-a-uuid", "6ccd780cbaba102695645b8c656024db"} {
+		got, err := convertUUIDToBinary(value, Options{})
+		if err == nil || got.Text != value {
+			t.Errorf("convertUUIDToBinary(%q) = %q, %v; want the value unchanged and an error", value, got.Text, err)
+		}
+	}
+}
+
+func TestUUIDColumn(t *testing.T) {
+	schema := "id,uniqueidentifier,id,BINARY(16)\n"
+	swapped, _, err := generate(t, schema, "id\n6ccd780c-baba-1026-9564-5b8c656024db\n", testOptions(t, "-uuid-swap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unswapped, _, err := generate(t, schema, "id\n6ccd780c-baba-1026-9564-5b8c656024db\n", testOptions(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(swapped, "(X'1026baba6ccd780c95645b8c656024db')") || !strings.Contains(unswapped, "(X'6ccd780cbaba102695645b8c656024db')") {
+		t.Errorf("swapped:\n%s\nunswapped:\n%s", swapped, unswapped)
+	}
+}
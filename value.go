@@ -0,0 +1,48 @@
+package main
+
+import "strings"
+
+type ValueKind int
+
+const (
+	StringValue  ValueKind = iota // クォートして出力する
+	NumberValue                   // 数値リテラルとしてそのまま出力する
+	KeywordValue                  // DEFAULT などの SQL キーワードとしてそのまま出力する
+)
+
+var defaultValue = Value{Text: "DEFAULT", Kind: KeywordValue}
+
+var nullValue = Value{Text: "NULL", Kind: KeywordValue}
+
+// Value は convertData で変換した後の、SQL に書き出す直前の値です。
+type Value struct {
+	Text string
+	Kind ValueKind
+}
+
+// SQL は値を SQL のリテラルとして返します。noBackslashEscapes は -sql-mode に
+// NO_BACKSLASH_ESCAPES を含めた場合で、文字列はクォートを重ねる ANSI の書き方でエスケープします。
+func (v Value) SQL(noBackslashEscapes bool) string {
+	if v.Kind == NumberValue || v.Kind == KeywordValue {
+		return v.Text
+	}
+	if noBackslashEscapes {
+		return "'" + strings.ReplaceAll(v.Text, "'", "''") + "'"
+	}
+	return "'" + escapeString(v.Text) + "'"
+}
+
+// MySQL の文字列リテラル内で特別な意味を持つ文字をエスケープします
+var stringEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"'", "\\'",
+	"\"", "\\\"",
+	"\x00", "\\0",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\x1a", "\\Z",
+)
+
+func escapeString(s string) string {
+	return stringEscaper.Replace(s)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestValueSQL(t *testing.T) {
+	tests := []struct {
+		value              Value
+		want               string
+		noBackslashEscapes string
+	}{
+		{Value{Text: "plain"}, "'plain'", "'plain'"},
+		{Value{Text: "O'Brien"}, `'O\'Brien'`, "'O''Brien'"},
+		{Value{Text: `C:\tmp`}, `'C:\\tmp'`, `'C:\tmp'`},
+		{Value{Text: "say \"hi\""}, `'say \"hi\"'`, `'say "hi"'`},
+		{Value{Text: "a\nb\r\x00\x1a"}, `'a\nb\r\0\Z'`, "'a\nb\r\x00\x1a'"},
+		{Value{Text: "''"}, `'\'\''`, "''''''"},
+		{Value{Text: "1.50", Kind: NumberValue}, "1.50", "1.50"},
+		{nullValue, "NULL", "NULL"},
+		{defaultValue, "DEFAULT", "DEFAULT"},
+	}
+	for _, tt := range tests {
+		if got := tt.value.SQL(false); got != tt.want {
+			t.Errorf("%q.SQL(false) = %s, want %s", tt.value.Text, got, tt.want)
+		}
+		if got := tt.value.SQL(true); got != tt.noBackslashEscapes {
+			t.Errorf("%q.SQL(true) = %q, want %q", tt.value.Text, got, tt.noBackslashEscapes)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// isYearSourceType は YEAR に変換できる SQL Server の型かどうかを返します。
+func isYearSourceType(srcType string) bool {
+	switch sourceTypeName(srcType) {
+	case "date", "datetime", "datetime2", "smalldatetime", "tinyint", "smallint", "int":
+		return true
+	}
+	return isTextSourceType(srcType)
+}
+
+// convertYear は日付、日時、または年だけの値を MySQL の YEAR に入れる4桁の年にします。
+// 文字列の日付は -date-format の書式も試します。1〜2桁の年は MySQL と同じく 1〜69 を 2001〜2069、
+// 70〜99 を 1970〜1999 と読み、出力では4桁に直してあいまいさをなくします。0 はそのまま 0 です。
+func convertYear(value string, options Options) (Value, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return Value{Text: value}, nil
+	}
+
+	year, ok := 0, false
+	if isInteger(trimmed) && len(strings.TrimLeft(trimmed, "+-")) <= 4 {
+		year, _ = strconv.Atoi(trimmed)
+		ok = true
+		if digits := len(strings.TrimLeft(trimmed, "+-")); digits <= 2 && year > 0 {
+			if year < 70 {
+				year += 2000
+			} else {
+				year += 1900
+			}
+		}
+	} else {
+		layouts := append([]string{"2006-01-02"}, datetimeLayouts...)
+		for _, layout := range append(layouts, options.DateFormats...) {
+			if t, err := time.Parse(layout, trimmed); err == nil {
+				year, ok = t.Year(), true
+				break
+			}
+		}
+	}
+	if !ok {
+		return Value{Text: value}, fmt.Errorf("%q is not a date or year; left unchanged", value)
+	}
+
+	if year != 0 && (year < 1901 || year > 2155) {
+		return Value{Text: value}, fmt.Errorf("year %d is out of range for YEAR (1901 to 2155, or 0)", year)
+	}
+	return Value{Text: strconv.Itoa(year), Kind: NumberValue}, nil
+}
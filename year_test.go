@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertYear(t *testing.T) {
+	options := testOptions(t, "-date-format", "02/01/2006")
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"2023", "2023", false},
+		{" 1999 ", "1999", false},
+		{"0", "0", false},
+		{"5", "2005", false},
+		{"69", "2069", false},
+		{"70", "1970", false},
+		{"99", "1999", false},
+		{"1901", "1901", false},
+		{"2155", "2155", false},
+		{"2023-04-05", "2023", false},
+		{"2023-04-05 13:14:15.123", "2023", false},
+		{"2023-04-05T13:14:15", "2023", false},
+		{"05/04/2023", "2023", false}, // -date-format の書式も試す
+		{"", "", false},
+		{"1900", "1900", true},
+		{"2156", "2156", true},
+		{"1850-01-01", "1850-01-01", true},
+		{"-5", "-5", true},
+		{"20230", "20230", true},
+		{"next year", "next year", true},
+	}
+	for _, tt := range tests {
+		got, err := convertYear(tt.value, options)
+		if got.Text != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("convertYear(%q) = %q, %v; want %q", tt.value, got.Text, err, tt.want)
+		}
+		if err == nil && tt.value != "" && got.Kind != NumberValue {
+			t.Errorf("convertYear(%q) is not written as a number", tt.value)
+		}
+	}
+}
+
+func TestYearColumn(t *testing.T) {
+	const schema = "born,date,born,YEAR\n" +
+		"created,datetime,created,YEAR\n" +
+		"model,nvarchar,model,YEAR\n" +
+		"fiscal,smallint,fiscal,YEAR\n" +
+		"label,nvarchar,label,VARCHAR(10)\n"
+	const input = "born,created,model,fiscal,label\n" +
+		"2001-02-03,2023-04-05 06:07:08,98,2024,1999\n" +
+		"1800-01-01,2023-04-05 06:07:08,2023,0,x\n"
+	sql, report, err := generate(t, schema, input, testOptions(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// YEAR は引用符なしの4桁、文字列のカラムはそのまま
+	for _, want := range []string{"(2001, 2023, 1998, 2024, '1999')", "('1800-01-01', 2023, 2023, 0, 'x')"} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("missing %s in\n%s", want, sql)
+		}
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0].Row != 2 || report.Warnings[0].Column != "born" {
+		t.Errorf("warnings %v, want one for row 2, column born", report.Warnings)
+	}
+}